@@ -1,6 +1,17 @@
 package batch
 
-import "fmt"
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"syscall"
+	"time"
+)
 
 type (
 	ConnectionOperationType string
@@ -17,23 +28,161 @@ const (
 	IoWrite IoOperationType = "write"
 )
 
+// ErrorCode is a stable code of an error, which can be used in metrics, alerts and retry decisions.
+type ErrorCode string
+
+const (
+	CodeUnknown          ErrorCode = "unknown"
+	CodeIllegalArgument  ErrorCode = "illegal_argument"
+	CodeCanceled         ErrorCode = "canceled"
+	CodeTimeout          ErrorCode = "timeout"
+	CodeConnection       ErrorCode = "connection"
+	CodeAuthentication   ErrorCode = "authentication"
+	CodeNotFound         ErrorCode = "not_found"
+	CodePermissionDenied ErrorCode = "permission_denied"
+	CodeIo               ErrorCode = "io"
+	CodeRateLimited      ErrorCode = "rate_limited"
+	CodeUnavailable      ErrorCode = "unavailable"
+	CodeInvalidStatus    ErrorCode = "invalid_status"
+	// CodeHostKeyMismatch is the code of a server whose host key is not the known one, e.g. a man in the middle
+	CodeHostKeyMismatch ErrorCode = "host_key_mismatch"
+)
+
+// Retryable reports whether an operation failed with the code may succeed if it is retried.
+func (c ErrorCode) Retryable() bool {
+	switch c {
+	case CodeTimeout, CodeConnection, CodeRateLimited, CodeUnavailable:
+		return true
+	default:
+		return false
+	}
+}
+
+// CodedError is implemented by the errors of this package.
+type CodedError interface {
+	error
+	Code() ErrorCode
+	Retryable() bool
+}
+
+var (
+	_ CodedError = (*IllegalArgumentError)(nil)
+	_ CodedError = (*ConnectionError)(nil)
+	_ CodedError = (*IoError)(nil)
+	_ CodedError = (*InvalidStatusError)(nil)
+)
+
+// CodeOf returns the code of the first CodedError in the chain of err.
+func CodeOf(err error) ErrorCode {
+	if err == nil {
+		return ""
+	}
+
+	var coded CodedError
+	if errors.As(err, &coded) {
+		return coded.Code()
+	}
+
+	return codeOfCause(err, CodeUnknown)
+}
+
+// IsRetryable reports whether the operation which failed with err may succeed if it is retried.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var coded CodedError
+	if errors.As(err, &coded) {
+		return coded.Retryable()
+	}
+
+	return codeOfCause(err, CodeUnknown).Retryable()
+}
+
+// IsPermanent reports whether the operation which failed with err would fail again if it is retried.
+func IsPermanent(err error) bool {
+	return err != nil && !IsRetryable(err)
+}
+
+// codeOfCause derives the code from the underlying cause of an error, e.g. a network or file system error.
+func codeOfCause(err error, fallback ErrorCode) ErrorCode {
+	var dnsErr *net.DNSError
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		return CodeCanceled
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, os.ErrDeadlineExceeded):
+		return CodeTimeout
+	case errors.Is(err, os.ErrNotExist):
+		return CodeNotFound
+	case errors.Is(err, os.ErrPermission):
+		return CodePermissionDenied
+	case errors.As(err, &dnsErr) && dnsErr.IsNotFound:
+		return CodeNotFound
+	case errors.Is(err, io.ErrUnexpectedEOF),
+		errors.Is(err, syscall.ECONNRESET),
+		errors.Is(err, syscall.ECONNREFUSED),
+		errors.Is(err, syscall.ECONNABORTED),
+		errors.Is(err, syscall.EPIPE):
+		return CodeConnection
+	}
+
+	var certErr *tls.CertificateVerificationError
+	if errors.As(err, &certErr) {
+		return CodeAuthentication
+	}
+
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		if opErr.Timeout() {
+			return CodeTimeout
+		}
+
+		return CodeConnection
+	}
+
+	return fallback
+}
+
 type IllegalArgumentError struct {
 	Name  string
 	Value any
+	Err   error // why the value is illegal, nil if obvious
 }
 
 func (e *IllegalArgumentError) Error() string {
-	if e.Value == nil {
-		return "illegal argument " + e.Name
+	msg := "illegal argument " + e.Name
+
+	if e.Value != nil {
+		msg = fmt.Sprintf("%s with value %v", msg, e.Value)
 	}
 
-	return fmt.Sprintf("illegal argument %s with value %v", e.Name, e.Value)
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+
+	return msg
+}
+
+func (e *IllegalArgumentError) Unwrap() error {
+	return e.Err
+}
+
+func (e *IllegalArgumentError) Code() ErrorCode {
+	return CodeIllegalArgument
+}
+
+func (e *IllegalArgumentError) Retryable() bool {
+	return false
 }
 
 type ConnectionError struct {
 	Operation ConnectionOperationType
 	Address   string
-	Err       error
+	// Reason overrides the code derived from Err, e.g. CodeAuthentication when the server rejects the credentials
+	Reason ErrorCode
+	Err    error
 }
 
 func (e *ConnectionError) Error() string {
@@ -44,6 +193,24 @@ func (e *ConnectionError) Unwrap() error {
 	return e.Err
 }
 
+// Code is CodeConnection unless the reason or the underlying error tells otherwise.
+func (e *ConnectionError) Code() ErrorCode {
+	if e.Reason != "" {
+		return e.Reason
+	}
+
+	var coded CodedError
+	if errors.As(e.Err, &coded) {
+		return coded.Code()
+	}
+
+	return codeOfCause(e.Err, CodeConnection)
+}
+
+func (e *ConnectionError) Retryable() bool {
+	return e.Code().Retryable()
+}
+
 type IoError struct {
 	Operation IoOperationType
 	Resource  string
@@ -58,10 +225,46 @@ func (e *IoError) Unwrap() error {
 	return e.Err
 }
 
+// Code is CodeIo unless the underlying error tells otherwise, e.g. a connection reset while reading.
+func (e *IoError) Code() ErrorCode {
+	var coded CodedError
+	if errors.As(e.Err, &coded) {
+		return coded.Code()
+	}
+
+	return codeOfCause(e.Err, CodeIo)
+}
+
+func (e *IoError) Retryable() bool {
+	return e.Code().Retryable()
+}
+
 type InvalidStatusError struct {
 	StatusCode int
+	RetryAfter time.Duration // from the Retry-After header, 0 if absent
 }
 
 func (e *InvalidStatusError) Error() string {
 	return fmt.Sprintf("invalid status %d", e.StatusCode)
 }
+
+func (e *InvalidStatusError) Code() ErrorCode {
+	switch e.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return CodeAuthentication
+	case http.StatusNotFound, http.StatusGone:
+		return CodeNotFound
+	case http.StatusRequestTimeout, http.StatusGatewayTimeout:
+		return CodeTimeout
+	case http.StatusTooManyRequests:
+		return CodeRateLimited
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable:
+		return CodeUnavailable
+	default:
+		return CodeInvalidStatus
+	}
+}
+
+func (e *InvalidStatusError) Retryable() bool {
+	return e.Code().Retryable()
+}
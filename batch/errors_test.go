@@ -0,0 +1,109 @@
+package batch
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+)
+
+func Test_ErrorClassification(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name      string
+		err       error
+		code      ErrorCode
+		retryable bool
+	}{
+		{
+			name: "service unavailable",
+			err:  &InvalidStatusError{StatusCode: 503},
+			code: CodeUnavailable, retryable: true,
+		},
+		{
+			name: "not found",
+			err:  &InvalidStatusError{StatusCode: 404},
+			code: CodeNotFound, retryable: false,
+		},
+		{
+			name: "too many requests",
+			err:  fmt.Errorf("could not fetch: %w", &InvalidStatusError{StatusCode: 429}),
+			code: CodeRateLimited, retryable: true,
+		},
+		{
+			name: "connection reset",
+			err: &ConnectionError{
+				Operation: ConnOpen, Address: "localhost:22",
+				Err: &net.OpError{Op: "read", Err: os.NewSyscallError("read", syscall.ECONNRESET)},
+			},
+			code: CodeConnection, retryable: true,
+		},
+		{
+			name: "authentication failure",
+			err:  &ConnectionError{Operation: ConnOpen, Address: "localhost:22", Reason: CodeAuthentication, Err: io.EOF},
+			code: CodeAuthentication, retryable: false,
+		},
+		{
+			name: "host key mismatch",
+			err:  &ConnectionError{Operation: ConnOpen, Address: "localhost:22", Reason: CodeHostKeyMismatch, Err: io.EOF},
+			code: CodeHostKeyMismatch, retryable: false,
+		},
+		{
+			name: "canceled",
+			err:  &ConnectionError{Operation: ConnOpen, Address: "localhost:22", Err: context.Canceled},
+			code: CodeCanceled, retryable: false,
+		},
+		{
+			name: "file not found",
+			err:  &IoError{Operation: IoOpen, Resource: "report.csv", Err: os.ErrNotExist},
+			code: CodeNotFound, retryable: false,
+		},
+		{
+			name: "truncated body",
+			err:  &IoError{Operation: IoRead, Resource: "report.csv", Err: io.ErrUnexpectedEOF},
+			code: CodeConnection, retryable: true,
+		},
+		{
+			name: "malformed record",
+			err:  &IoError{Operation: IoRead, Resource: "report.csv", Err: fmt.Errorf("wrong number of fields")},
+			code: CodeIo, retryable: false,
+		},
+		{
+			name: "illegal argument",
+			err:  &IllegalArgumentError{Name: "resource"},
+			code: CodeIllegalArgument, retryable: false,
+		},
+		{
+			name: "illegal argument with cause",
+			err:  &IllegalArgumentError{Name: "url", Value: "{{.start", Err: fmt.Errorf("unclosed action")},
+			code: CodeIllegalArgument, retryable: false,
+		},
+		{
+			name: "unknown",
+			err:  fmt.Errorf("boom"),
+			code: CodeUnknown, retryable: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if code := CodeOf(tc.err); code != tc.code {
+				t.Fatalf("expected code %s, got %s", tc.code, code)
+			}
+
+			if retryable := IsRetryable(tc.err); retryable != tc.retryable {
+				t.Fatalf("expected retryable %t, got %t", tc.retryable, retryable)
+			}
+
+			if permanent := IsPermanent(tc.err); permanent == tc.retryable {
+				t.Fatalf("expected permanent %t, got %t", !tc.retryable, permanent)
+			}
+		})
+	}
+}
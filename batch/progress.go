@@ -0,0 +1,18 @@
+package batch
+
+// Sizer is implemented by resources which know their total size in bytes once opened.
+type Sizer interface {
+	// Size returns the total size in bytes, -1 if unknown
+	Size() int64
+}
+
+type Progress struct {
+	TotalBytes int64 // -1 if unknown
+	BytesRead  int64
+	Records    int64
+}
+
+// ProgressReporter is implemented by readers which report how much they have consumed.
+type ProgressReporter interface {
+	GetProgress() Progress
+}
@@ -0,0 +1,49 @@
+package reader
+
+import (
+	"io"
+	"sync/atomic"
+
+	"github.com/ivxivx/go-recon/batch"
+)
+
+// countingReader counts bytes read from a resource, it is safe to get the progress from another goroutine.
+// It must be created after the resource is opened, so that the size of the resource is known.
+type countingReader struct {
+	resource batch.ResourceReader
+
+	totalBytes int64
+	bytesRead  atomic.Int64
+	records    atomic.Int64
+}
+
+func newCountingReader(resource batch.ResourceReader) *countingReader {
+	totalBytes := int64(-1)
+
+	if sizer, cok := resource.(batch.Sizer); cok {
+		totalBytes = sizer.Size()
+	}
+
+	return &countingReader{
+		resource:   resource,
+		totalBytes: totalBytes,
+	}
+}
+
+var _ io.Reader = (*countingReader)(nil)
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.resource.Read(p)
+
+	r.bytesRead.Add(int64(n))
+
+	return n, err
+}
+
+func (r *countingReader) getProgress() batch.Progress {
+	return batch.Progress{
+		TotalBytes: r.totalBytes,
+		BytesRead:  r.bytesRead.Load(),
+		Records:    r.records.Load(),
+	}
+}
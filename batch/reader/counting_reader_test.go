@@ -0,0 +1,71 @@
+package reader
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/ivxivx/go-recon/batch"
+)
+
+// testResource reads a string without reporting its size.
+type testResource struct {
+	io.Reader
+}
+
+func (r *testResource) Open(_ context.Context) error  { return nil }
+func (r *testResource) Close(_ context.Context) error { return nil }
+func (r *testResource) GetID() string                 { return "test" }
+
+type testSizedResource struct {
+	*testResource
+
+	size int64
+}
+
+func (r *testSizedResource) Size() int64 { return r.size }
+
+func Test_CountingReader(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		resource batch.ResourceReader
+		expected batch.Progress
+	}{
+		{
+			name:     "sized",
+			resource: &testSizedResource{testResource: &testResource{Reader: strings.NewReader("id,amount\n1,10\n")}, size: 15},
+			expected: batch.Progress{TotalBytes: 15, BytesRead: 15, Records: 1},
+		},
+		{
+			name:     "size unknown",
+			resource: &testResource{Reader: strings.NewReader("id,amount\n1,10\n")},
+			expected: batch.Progress{TotalBytes: -1, BytesRead: 15, Records: 1},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			counter := newCountingReader(tc.resource)
+
+			if progress := counter.getProgress(); progress.TotalBytes != tc.expected.TotalBytes || progress.BytesRead != 0 {
+				t.Fatalf("unexpected progress before reading %+v", progress)
+			}
+
+			_, err := io.Copy(io.Discard, counter)
+			if err != nil {
+				t.Fatalf("failed to read: %v", err)
+			}
+
+			counter.records.Add(1)
+
+			if progress := counter.getProgress(); progress != tc.expected {
+				t.Fatalf("expected %+v, got %+v", tc.expected, progress)
+			}
+		})
+	}
+}
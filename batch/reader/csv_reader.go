@@ -10,6 +10,7 @@ import (
 	"os"
 	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/jszwec/csvutil"
 
@@ -29,6 +30,7 @@ type CsvReader struct {
 	closeOnce sync.Once
 
 	decoder *csvutil.Decoder
+	counter atomic.Pointer[countingReader]
 }
 
 func NewCsvReader(
@@ -63,7 +65,10 @@ func (r *CsvReader) WithSkipNonExist(skip bool) *CsvReader {
 	return r
 }
 
-var _ batch.Reader = (*CsvReader)(nil)
+var (
+	_ batch.Reader           = (*CsvReader)(nil)
+	_ batch.ProgressReporter = (*CsvReader)(nil)
+)
 
 func (r *CsvReader) Open(ctx context.Context) error {
 	if r.resource == nil {
@@ -93,26 +98,42 @@ func (r *CsvReader) Open(ctx context.Context) error {
 			return
 		}
 
-		reader := csv.NewReader(r.resource)
+		counter := newCountingReader(r.resource)
 
-		decoder, err := csvutil.NewDecoder(reader)
+		decoder, err := r.newDecoder(counter)
 		if err != nil {
-			errR = &BadFormatError{
-				ResourceID: r.resource.GetID(),
-				Err:        err,
-			}
+			errR = err
 
 			return
 		}
 
-		decoder.Map = r.transformField
-
 		r.decoder = decoder
+		r.counter.Store(counter)
 	})
 
+	if errR != nil {
+		// a failed open can be retried
+		r.openOnce = sync.Once{}
+	}
+
 	return errR
 }
 
+// newDecoder reads the header of the resource, or of the current page of a paged resource.
+func (r *CsvReader) newDecoder(counter *countingReader) (*csvutil.Decoder, error) {
+	decoder, err := csvutil.NewDecoder(csv.NewReader(counter))
+	if err != nil {
+		return nil, &BadFormatError{
+			ResourceID: r.resource.GetID(),
+			Err:        err,
+		}
+	}
+
+	decoder.Map = r.transformField
+
+	return decoder, nil
+}
+
 func (r *CsvReader) transformField(field, col string, _ any) string {
 	val := field
 
@@ -156,7 +177,8 @@ func (r *CsvReader) Close(ctx context.Context) error {
 	return errR
 }
 
-func (r *CsvReader) Read(_ context.Context, record any) error {
+// Read reads the next record, each page of a paged resource is a CSV file with its own header, e.g. the files matched by a pattern.
+func (r *CsvReader) Read(ctx context.Context, record any) error {
 	if r.decoder == nil {
 		if r.skipNonExist {
 			return io.EOF
@@ -167,9 +189,48 @@ func (r *CsvReader) Read(_ context.Context, record any) error {
 
 	err := r.decoder.Decode(record)
 
-	if err == nil || errors.Is(err, io.EOF) {
-		return err
+	for errors.Is(err, io.EOF) {
+		paged, cok := r.resource.(batch.PagedResource)
+		if !cok {
+			return err
+		}
+
+		if errP := paged.NextPage(ctx); errP != nil {
+			if errors.Is(errP, io.EOF) {
+				return io.EOF
+			}
+
+			return fmt.Errorf("could not get next page: %w", errP)
+		}
+
+		decoder, errD := r.newDecoder(r.counter.Load())
+		if errD != nil {
+			// an empty page has no header
+			if errors.Is(errD, io.EOF) {
+				continue
+			}
+
+			return errD
+		}
+
+		r.decoder = decoder
+		err = r.decoder.Decode(record)
+	}
+
+	if err == nil {
+		r.counter.Load().records.Add(1)
+
+		return nil
 	}
 
 	return &batch.IoError{Operation: batch.IoRead, Resource: r.resource.GetID(), Err: err}
 }
+
+func (r *CsvReader) GetProgress() batch.Progress {
+	counter := r.counter.Load()
+	if counter == nil {
+		return batch.Progress{TotalBytes: -1}
+	}
+
+	return counter.getProgress()
+}
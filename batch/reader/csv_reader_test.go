@@ -0,0 +1,78 @@
+package reader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	rs "github.com/ivxivx/go-recon/batch/resource"
+)
+
+type testCsvRecord struct {
+	ID     string `csv:"id"`
+	Amount string `csv:"amount"`
+}
+
+func Test_CsvReader_Paged(t *testing.T) {
+	t.Parallel()
+
+	// each page has its own header, as the files matched by a pattern, the columns may be in a different order
+	pages := []string{
+		"id,amount\n1,10.00\n2,20.00\n",
+		"",
+		"amount,id\n30.00,3\n",
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("p"))
+
+		if page+1 < len(pages) {
+			w.Header().Set("Link", fmt.Sprintf(`</reports?p=%d>; rel="next"`, page+1))
+		}
+
+		_, _ = w.Write([]byte(pages[page]))
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+
+	resource := rs.NewPaginatedHTTPResource(slog.Default(), rs.NewHTTPResource(slog.Default(), server.URL+"/reports"), &rs.LinkHeaderPaginator{})
+
+	reader := NewCsvReader(slog.Default(), resource)
+
+	if err := reader.Open(ctx); err != nil {
+		t.Fatalf("failed to open reader: %v", err)
+	}
+
+	defer reader.Close(ctx)
+
+	var records []testCsvRecord
+
+	for {
+		var record testCsvRecord
+
+		err := reader.Read(ctx, &record)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			t.Fatalf("failed to read: %v", err)
+		}
+
+		records = append(records, record)
+	}
+
+	expected := []testCsvRecord{{ID: "1", Amount: "10.00"}, {ID: "2", Amount: "20.00"}, {ID: "3", Amount: "30.00"}}
+	if diff := cmp.Diff(expected, records); diff != "" {
+		t.Fatalf("unexpected records (-want +got):\n%s", diff)
+	}
+}
@@ -2,15 +2,18 @@ package reader
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"reflect"
 	"sync"
+	"sync/atomic"
 
 	"github.com/goccy/go-json"
 
 	"github.com/ivxivx/go-recon/batch"
+	rs "github.com/ivxivx/go-recon/batch/resource"
 	"github.com/ivxivx/go-recon/batch/transformer"
 )
 
@@ -21,10 +24,12 @@ type JSONReader struct {
 
 	openOnce  sync.Once
 	closeOnce sync.Once
-	readOnce  sync.Once
 
+	loaded  bool
+	loadErr error // of the page which failed to load, its stream is consumed so it cannot be loaded again
 	records []any
 	index   int
+	counter atomic.Pointer[countingReader]
 }
 
 func NewJSONReader(
@@ -39,7 +44,10 @@ func NewJSONReader(
 	}
 }
 
-var _ batch.Reader = (*JSONReader)(nil)
+var (
+	_ batch.Reader           = (*JSONReader)(nil)
+	_ batch.ProgressReporter = (*JSONReader)(nil)
+)
 
 func (r *JSONReader) Open(ctx context.Context) error {
 	if r.resource == nil {
@@ -52,9 +60,18 @@ func (r *JSONReader) Open(ctx context.Context) error {
 		err := r.resource.Open(ctx)
 		if err != nil {
 			errR = fmt.Errorf("could not open resource: %w", err)
+
+			return
 		}
+
+		r.counter.Store(newCountingReader(r.resource))
 	})
 
+	if errR != nil {
+		// a failed open can be retried
+		r.openOnce = sync.Once{}
+	}
+
 	return errR
 }
 
@@ -78,53 +95,86 @@ func (r *JSONReader) Close(ctx context.Context) error {
 	return errR
 }
 
+// Read reads the next record, the records of a paged resource are read page by page as one stream.
 func (r *JSONReader) Read(ctx context.Context, record any) error {
 	if record == nil {
 		return &batch.IllegalArgumentError{Name: "record"}
 	}
 
-	var errR error
+	for r.index >= len(r.records) {
+		if r.loadErr != nil {
+			return r.loadErr
+		}
 
-	r.readOnce.Do(func() {
-		rawData, err := io.ReadAll(r.resource)
-		if err != nil {
-			errR = &batch.IoError{Operation: batch.IoRead, Resource: r.resource.GetID(), Err: err}
+		if r.loaded {
+			paged, cok := r.resource.(batch.PagedResource)
+			if !cok {
+				return io.EOF
+			}
 
-			return
+			if err := paged.NextPage(ctx); err != nil {
+				if errors.Is(err, io.EOF) {
+					return io.EOF
+				}
+
+				return fmt.Errorf("could not get next page: %w", err)
+			}
 		}
 
-		records, err := r.transform(ctx, rawData)
-		if err != nil {
-			errR = err
+		if err := r.load(ctx); err != nil {
+			r.loadErr = err
 
-			return
+			return err
 		}
+	}
 
-		r.records = records
-		r.index = 0
-	})
+	outValue := reflect.ValueOf(record).Elem()
+	inValue := reflect.ValueOf(r.records[r.index])
+	outValue.Set(inValue)
 
-	if errR != nil {
-		return errR
-	}
+	r.index++
+
+	r.counter.Load().records.Add(1)
+
+	return nil
+}
+
+// load reads the whole resource, or the current page of a paged resource, and extracts the records.
+func (r *JSONReader) load(ctx context.Context) error {
+	r.loaded = false
+	r.records = nil
+	r.index = 0
 
-	if r.records == nil {
-		return io.EOF
+	counter := r.counter.Load()
+	if counter == nil {
+		return &batch.IoError{Operation: batch.IoRead, Resource: r.resource.GetID(), Err: rs.ErrResourceNotOpened}
 	}
 
-	if r.index >= len(r.records) {
-		return io.EOF
+	rawData, err := io.ReadAll(counter)
+	if err != nil {
+		return &batch.IoError{Operation: batch.IoRead, Resource: r.resource.GetID(), Err: err}
 	}
 
-	outValue := reflect.ValueOf(record).Elem()
-	inValue := reflect.ValueOf(r.records[r.index])
-	outValue.Set(inValue)
+	records, err := r.transform(ctx, rawData)
+	if err != nil {
+		return err
+	}
 
-	r.index++
+	r.records = records
+	r.loaded = true
 
 	return nil
 }
 
+func (r *JSONReader) GetProgress() batch.Progress {
+	counter := r.counter.Load()
+	if counter == nil {
+		return batch.Progress{TotalBytes: -1}
+	}
+
+	return counter.getProgress()
+}
+
 func (r *JSONReader) transform(ctx context.Context, raw []byte) ([]any, error) {
 	dataType := r.recordExtractor.GetInputDataType()
 
@@ -0,0 +1,210 @@
+package reader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/google/go-cmp/cmp"
+
+	rs "github.com/ivxivx/go-recon/batch/resource"
+	"github.com/ivxivx/go-recon/batch/transformer"
+)
+
+type testRecord struct {
+	ID string `json:"id"`
+}
+
+type testPage struct {
+	Data []*testRecord `json:"data"`
+	Next *string       `json:"next"`
+}
+
+type testRecordExtractor struct{}
+
+func (e *testRecordExtractor) GetInputDataType() any {
+	return &testPage{}
+}
+
+func (e *testRecordExtractor) Extract(_ context.Context, data any) ([]any, error) {
+	page, cok := data.(*testPage)
+	if !cok {
+		return nil, fmt.Errorf("unexpected type %T", data)
+	}
+
+	return transformer.ConvertSlice(page.Data), nil
+}
+
+// testPages are the pages of 5 records, with 2 records per page.
+var testPages = [][]*testRecord{
+	{{ID: "1"}, {ID: "2"}},
+	{{ID: "3"}, {ID: "4"}},
+	{{ID: "5"}},
+}
+
+func Test_JSONReader_Paginated(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name      string
+		paginator rs.Paginator
+		// page returns the index of the requested page, and writes the pagination info of the response
+		page func(w http.ResponseWriter, r *http.Request, body *testPage) int
+	}{
+		{
+			name:      "offset",
+			paginator: rs.NewOffsetPaginator("page", "size", 2).WithRecordsField("data"),
+			page: func(_ http.ResponseWriter, r *http.Request, _ *testPage) int {
+				page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+
+				return page - 1
+			},
+		},
+		{
+			name:      "cursor",
+			paginator: rs.NewCursorPaginator("next", "cursor"),
+			page: func(_ http.ResponseWriter, r *http.Request, body *testPage) int {
+				page := 0
+				if cursor := r.URL.Query().Get("cursor"); cursor != "" {
+					page, _ = strconv.Atoi(cursor)
+				}
+
+				if page+1 < len(testPages) {
+					next := strconv.Itoa(page + 1)
+					body.Next = &next
+				}
+
+				return page
+			},
+		},
+		{
+			name:      "link header",
+			paginator: &rs.LinkHeaderPaginator{},
+			page: func(w http.ResponseWriter, r *http.Request, _ *testPage) int {
+				page, _ := strconv.Atoi(r.URL.Query().Get("p"))
+
+				if page+1 < len(testPages) {
+					w.Header().Set("Link", fmt.Sprintf(`</transactions?p=%d>; rel="next", </transactions?p=0>; rel="first"`, page+1))
+				}
+
+				return page
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			requests := 0
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+
+				body := &testPage{}
+
+				page := tc.page(w, r, body)
+				if page < 0 || page >= len(testPages) {
+					w.WriteHeader(http.StatusNotFound)
+
+					return
+				}
+
+				body.Data = testPages[page]
+
+				if err := json.NewEncoder(w).Encode(body); err != nil {
+					t.Errorf("failed to write response: %v", err)
+				}
+			}))
+			defer server.Close()
+
+			ctx := context.Background()
+
+			resource := rs.NewPaginatedHTTPResource(slog.Default(), rs.NewHTTPResource(slog.Default(), server.URL+"/transactions"), tc.paginator)
+
+			reader := NewJSONReader(slog.Default(), resource, &testRecordExtractor{})
+
+			if err := reader.Open(ctx); err != nil {
+				t.Fatalf("failed to open reader: %v", err)
+			}
+
+			defer reader.Close(ctx)
+
+			var ids []string
+
+			for {
+				var record *testRecord
+
+				err := reader.Read(ctx, &record)
+				if errors.Is(err, io.EOF) {
+					break
+				}
+
+				if err != nil {
+					t.Fatalf("failed to read: %v", err)
+				}
+
+				ids = append(ids, record.ID)
+			}
+
+			if expected := []string{"1", "2", "3", "4", "5"}; !cmp.Equal(ids, expected) {
+				t.Fatalf("expected records %v, got %v", expected, ids)
+			}
+
+			if requests != len(testPages) {
+				t.Fatalf("expected %d requests, got %d", len(testPages), requests)
+			}
+		})
+	}
+}
+
+func Test_JSONReader_PageFailed(t *testing.T) {
+	t.Parallel()
+
+	// the records of the second page are malformed, though it links to the third
+	pages := []string{
+		`{"data":[{"id":"1"}],"next":"1"}`,
+		`{"data":"oops","next":"2"}`,
+		`{"data":[{"id":"3"}]}`,
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("cursor"))
+
+		_, _ = w.Write([]byte(pages[page]))
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+
+	resource := rs.NewPaginatedHTTPResource(slog.Default(), rs.NewHTTPResource(slog.Default(), server.URL), rs.NewCursorPaginator("next", "cursor"))
+
+	reader := NewJSONReader(slog.Default(), resource, &testRecordExtractor{})
+
+	if err := reader.Open(ctx); err != nil {
+		t.Fatalf("failed to open reader: %v", err)
+	}
+
+	defer reader.Close(ctx)
+
+	var record *testRecord
+
+	if err := reader.Read(ctx, &record); err != nil || record.ID != "1" {
+		t.Fatalf("expected record 1, got %v, %v", record, err)
+	}
+
+	// the failed page is not skipped
+	for range 2 {
+		err := reader.Read(ctx, &record)
+		if err == nil || errors.Is(err, io.EOF) {
+			t.Fatalf("expected the page to fail, got %v", err)
+		}
+	}
+}
@@ -24,3 +24,11 @@ type ResourceWriter interface {
 	Resource
 	io.Writer
 }
+
+// PagedResource is a resource whose data is split into pages, e.g. the response of a paginated API.
+// Each page is read to the end before moving to the next one.
+type PagedResource interface {
+	ResourceReader
+	// NextPage moves to the next page, it returns io.EOF if there are no more pages
+	NextPage(ctx context.Context) error
+}
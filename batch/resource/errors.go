@@ -1,5 +1,63 @@
 package resource
 
-import "errors"
+import (
+	"context"
+	"errors"
 
-var ErrResourceNotOpened = errors.New("resource not opened")
+	"github.com/ivxivx/go-recon/batch"
+)
+
+var (
+	ErrResourceNotOpened = errors.New("resource not opened")
+	ErrStreamedBody      = errors.New("streamed body cannot be signed")
+	ErrNoAnswer          = errors.New("no answer to question")
+	ErrNoSSHConfig       = errors.New("ssh config is nil")
+)
+
+// contextError returns the cause of ctx if it is done, as it is the cause of err then, e.g. a closed connection.
+func contextError(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return context.Cause(ctx)
+	}
+
+	return err
+}
+
+// CertificatePinError is returned if the certificate of a server does not match any pinned fingerprint.
+type CertificatePinError struct {
+	Fingerprint string
+}
+
+var _ batch.CodedError = (*CertificatePinError)(nil)
+
+func (e *CertificatePinError) Error() string {
+	return "server certificate with fingerprint " + e.Fingerprint + " is not pinned"
+}
+
+func (e *CertificatePinError) Code() batch.ErrorCode {
+	return batch.CodeAuthentication
+}
+
+func (e *CertificatePinError) Retryable() bool {
+	return false
+}
+
+// HostKeyMismatchError is returned if the host key of an ssh server does not match any known fingerprint.
+type HostKeyMismatchError struct {
+	Hostname    string
+	Fingerprint string
+}
+
+var _ batch.CodedError = (*HostKeyMismatchError)(nil)
+
+func (e *HostKeyMismatchError) Error() string {
+	return "host key of " + e.Hostname + " with fingerprint " + e.Fingerprint + " is not known"
+}
+
+func (e *HostKeyMismatchError) Code() batch.ErrorCode {
+	return batch.CodeHostKeyMismatch
+}
+
+func (e *HostKeyMismatchError) Retryable() bool {
+	return false
+}
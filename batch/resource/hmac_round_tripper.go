@@ -0,0 +1,225 @@
+package resource
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strconv"
+	"text/template"
+	"time"
+
+	"github.com/ivxivx/go-recon/batch"
+)
+
+const (
+	HMACSHA1   = "sha1"
+	HMACSHA256 = "sha256"
+	HMACSHA512 = "sha512"
+
+	EncodingHex    = "hex"
+	EncodingBase64 = "base64"
+
+	TimestampUnix   = "unix"
+	TimestampUnixMs = "unix_ms"
+)
+
+// HMACConfig describes how a provider expects requests to be signed.
+type HMACConfig struct {
+	Secret          []byte
+	Algorithm       string // sha1, sha256 or sha512, default is sha256
+	Encoding        string // hex or base64, default is hex
+	SignatureHeader string // e.g. X-Signature
+	SignaturePrefix string // e.g. "sha256=", prepended to the encoded signature
+	TimestampHeader string // e.g. X-Timestamp, empty if the timestamp is not sent
+	TimestampFormat string // unix, unix_ms or a time layout, default is unix
+	KeyID           string
+	KeyIDHeader     string // e.g. X-Key-Id, empty if the key ID is not sent
+}
+
+// SigningRequest is the part of a request which may be signed.
+type SigningRequest struct {
+	Method    string
+	Path      string // escaped
+	Query     string // raw query
+	Timestamp string // formatted as the timestamp header
+	Header    http.Header
+	Body      string
+	// BodySHA256 is the hex encoded SHA-256 of the body, as some providers sign the hash instead of the body
+	BodySHA256 string
+}
+
+// Canonicalizer builds the message to sign from a request, providers differ in which parts are signed and how.
+type Canonicalizer interface {
+	Canonicalize(req *SigningRequest) ([]byte, error)
+}
+
+var _ Canonicalizer = (*TemplateCanonicalizer)(nil)
+
+// TemplateCanonicalizer builds the message to sign with a template, so that a provider's scheme can be described in config,
+// e.g. "{{.Method}}\n{{.Path}}\n{{.Timestamp}}\n{{.Body}}".
+type TemplateCanonicalizer struct {
+	template *template.Template
+}
+
+func NewTemplateCanonicalizer(text string) (*TemplateCanonicalizer, error) {
+	tmpl, err := template.New("canonical").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, &batch.IllegalArgumentError{Name: "canonicalization template", Value: text, Err: err}
+	}
+
+	return &TemplateCanonicalizer{template: tmpl}, nil
+}
+
+func (c *TemplateCanonicalizer) Canonicalize(req *SigningRequest) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := c.template.Execute(&buf, req); err != nil {
+		return nil, fmt.Errorf("could not canonicalize request: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// HMACRoundTripper signs each request with an HMAC over its canonical form, and sends the signature in headers.
+// It composes with HTTPInterceptor, headers set by the interceptor can be signed as well.
+type HMACRoundTripper struct {
+	config        *HMACConfig
+	canonicalizer Canonicalizer
+	delegate      http.RoundTripper
+	now           func() time.Time
+
+	newHash func() hash.Hash
+	encode  func(sum []byte) string
+}
+
+// NewHMACRoundTripper fails if the algorithm or the encoding of the config is not supported.
+func NewHMACRoundTripper(config *HMACConfig, canonicalizer Canonicalizer, delegate http.RoundTripper) (*HMACRoundTripper, error) {
+	var newHash func() hash.Hash
+
+	switch config.Algorithm {
+	case "", HMACSHA256:
+		newHash = sha256.New
+	case HMACSHA512:
+		newHash = sha512.New
+	case HMACSHA1:
+		newHash = sha1.New
+	default:
+		return nil, &batch.IllegalArgumentError{Name: "algorithm", Value: config.Algorithm}
+	}
+
+	var encode func(sum []byte) string
+
+	switch config.Encoding {
+	case "", EncodingHex:
+		encode = hex.EncodeToString
+	case EncodingBase64:
+		encode = base64.StdEncoding.EncodeToString
+	default:
+		return nil, &batch.IllegalArgumentError{Name: "encoding", Value: config.Encoding}
+	}
+
+	return &HMACRoundTripper{
+		config:        config,
+		canonicalizer: canonicalizer,
+		delegate:      delegate,
+		now:           time.Now,
+		newHash:       newHash,
+		encode:        encode,
+	}, nil
+}
+
+// WithClock sets the clock of the timestamp, e.g. a fixed time in tests.
+func (rt *HMACRoundTripper) WithClock(now func() time.Time) *HMACRoundTripper {
+	rt.now = now
+
+	return rt
+}
+
+var _ http.RoundTripper = (*HMACRoundTripper)(nil)
+
+func (rt *HMACRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// a round tripper must not modify the request
+	signed := req.Clone(req.Context())
+	if body != nil {
+		signed.Body = io.NopCloser(bytes.NewReader(body))
+		signed.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(body)), nil
+		}
+	}
+
+	timestamp := rt.formatTimestamp(rt.now())
+
+	if rt.config.TimestampHeader != "" {
+		signed.Header.Set(rt.config.TimestampHeader, timestamp)
+	}
+
+	if rt.config.KeyIDHeader != "" {
+		signed.Header.Set(rt.config.KeyIDHeader, rt.config.KeyID)
+	}
+
+	bodyHash := sha256.Sum256(body)
+
+	message, err := rt.canonicalizer.Canonicalize(&SigningRequest{
+		Method:     signed.Method,
+		Path:       signed.URL.EscapedPath(),
+		Query:      signed.URL.RawQuery,
+		Timestamp:  timestamp,
+		Header:     signed.Header,
+		Body:       string(body),
+		BodySHA256: hex.EncodeToString(bodyHash[:]),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	mac := hmac.New(rt.newHash, rt.config.Secret)
+	mac.Write(message)
+
+	signed.Header.Set(rt.config.SignatureHeader, rt.config.SignaturePrefix+rt.encode(mac.Sum(nil)))
+
+	return rt.delegate.RoundTrip(signed)
+}
+
+func (rt *HMACRoundTripper) formatTimestamp(now time.Time) string {
+	switch rt.config.TimestampFormat {
+	case "", TimestampUnix:
+		return strconv.FormatInt(now.Unix(), 10)
+	case TimestampUnixMs:
+		return strconv.FormatInt(now.UnixMilli(), 10)
+	default:
+		return now.UTC().Format(rt.config.TimestampFormat)
+	}
+}
+
+// readBody reads and closes the body of a request, nil if there is no body. A body which cannot be replayed
+// is streamed, e.g. by HTTPUploadResource in streaming mode, it is rejected instead of read into memory.
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+
+	defer req.Body.Close()
+
+	if req.GetBody == nil {
+		return nil, &batch.IllegalArgumentError{Name: "body", Err: ErrStreamedBody}
+	}
+
+	data, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, fmt.Errorf("could not read request body: %w", err)
+	}
+
+	return data, nil
+}
@@ -0,0 +1,150 @@
+package resource
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ivxivx/go-recon/batch"
+)
+
+func Test_HMACRoundTripper(t *testing.T) {
+	t.Parallel()
+
+	clock := time.Date(2024, 8, 1, 10, 30, 0, 0, time.UTC)
+
+	type filter struct {
+		Party2ID string `json:"party2_id"`
+	}
+
+	testCases := []struct {
+		name        string
+		config      *HMACConfig
+		canonical   string
+		headers     map[string]string
+		body        any
+		query       map[string]string
+		timestamp   string
+		expectedMsg string
+		newHash     func() hash.Hash
+		encode      func([]byte) string
+	}{
+		{
+			name: "hex sha256 over method, path, timestamp and body",
+			config: &HMACConfig{
+				Secret:          []byte("secret"),
+				SignatureHeader: "X-Signature",
+				TimestampHeader: "X-Timestamp",
+			},
+			canonical:   "{{.Method}}\n{{.Path}}\n{{.Timestamp}}\n{{.Body}}",
+			body:        &filter{Party2ID: "zhang"},
+			timestamp:   "1722508200",
+			expectedMsg: "POST\n/transactions\n1722508200\n" + `{"party2_id":"zhang"}`,
+			newHash:     sha256.New,
+			encode:      hex.EncodeToString,
+		},
+		{
+			name: "base64 sha512 over query, header and body hash",
+			config: &HMACConfig{
+				Secret:          []byte("secret"),
+				Algorithm:       HMACSHA512,
+				Encoding:        EncodingBase64,
+				SignatureHeader: "Signature",
+				SignaturePrefix: "v1=",
+				TimestampHeader: "Date",
+				TimestampFormat: time.RFC3339,
+				KeyID:           "key-1",
+				KeyIDHeader:     "X-Key-Id",
+			},
+			canonical:   `{{.Method}} {{.Path}}?{{.Query}} {{.Header.Get "X-Key-Id"}} {{.Header.Get "X-Merchant"}} {{.Timestamp}} {{.BodySHA256}}`,
+			headers:     map[string]string{"X-Merchant": "recon"},
+			query:       map[string]string{"from": "2024-08-01"},
+			timestamp:   "2024-08-01T10:30:00Z",
+			expectedMsg: "GET /transactions?from=2024-08-01 key-1 recon 2024-08-01T10:30:00Z e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+			newHash:     sha512.New,
+			encode:      base64.StdEncoding.EncodeToString,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			mac := hmac.New(tc.newHash, tc.config.Secret)
+			mac.Write([]byte(tc.expectedMsg))
+			expectedSignature := tc.config.SignaturePrefix + tc.encode(mac.Sum(nil))
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if timestamp := r.Header.Get(tc.config.TimestampHeader); timestamp != tc.timestamp {
+					t.Errorf("expected timestamp %s, got %s", tc.timestamp, timestamp)
+				}
+
+				if signature := r.Header.Get(tc.config.SignatureHeader); signature != expectedSignature {
+					t.Errorf("expected signature %s, got %s", expectedSignature, signature)
+				}
+
+				_, _ = w.Write([]byte(`[]`))
+			}))
+			defer server.Close()
+
+			canonicalizer, err := NewTemplateCanonicalizer(tc.canonical)
+			if err != nil {
+				t.Fatalf("failed to create canonicalizer: %v", err)
+			}
+
+			roundTripper, err := NewHMACRoundTripper(tc.config, canonicalizer, http.DefaultTransport)
+			if err != nil {
+				t.Fatalf("failed to create round tripper: %v", err)
+			}
+
+			roundTripper.WithClock(func() time.Time { return clock })
+
+			resource := NewHTTPResourceWithTransport(slog.Default(), server.URL+"/transactions", tc.headers, roundTripper).
+				WithQuery(tc.query)
+
+			if tc.body != nil {
+				resource = resource.WithMethod(http.MethodPost).WithJSONBody(tc.body)
+			}
+
+			ctx := context.Background()
+
+			if err := resource.Open(ctx); err != nil {
+				t.Fatalf("failed to open resource: %v", err)
+			}
+
+			defer resource.Close(ctx)
+		})
+	}
+}
+
+func Test_HMACRoundTripper_InvalidConfig(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewTemplateCanonicalizer("{{.Method")
+
+	var argErr *batch.IllegalArgumentError
+	if !errors.As(err, &argErr) || argErr.Err == nil {
+		t.Fatalf("expected illegal template with its parse error, got %v", err)
+	}
+
+	canonicalizer, err := NewTemplateCanonicalizer("{{.Method}}")
+	if err != nil {
+		t.Fatalf("failed to create canonicalizer: %v", err)
+	}
+
+	for _, config := range []*HMACConfig{{Algorithm: "md5"}, {Encoding: "base32"}} {
+		if _, err := NewHMACRoundTripper(config, canonicalizer, http.DefaultTransport); batch.CodeOf(err) != batch.CodeIllegalArgument {
+			t.Fatalf("expected illegal argument for %+v, got %v", config, err)
+		}
+	}
+}
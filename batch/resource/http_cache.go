@@ -0,0 +1,141 @@
+package resource
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/goccy/go-json"
+)
+
+type HTTPCacheMode string
+
+const (
+	// HTTPCacheRevalidate sends a conditional request with the ETag and Last-Modified of the cached response,
+	// the cached response is served if the server replies 304.
+	HTTPCacheRevalidate HTTPCacheMode = "revalidate"
+	// HTTPCachePreferred serves the cached response without contacting the server, e.g. to reproduce a past run
+	// even if the provider has changed the data since. The server is contacted only if nothing is cached.
+	HTTPCachePreferred HTTPCacheMode = "preferred"
+)
+
+const defaultCacheDirMode = 0o750
+
+// HTTPCache keeps responses in a directory, keyed by the method, the URL and the body of the request.
+// Each response is kept in one file: its metadata as a JSON line, followed by the body.
+type HTTPCache struct {
+	dir  string
+	mode HTTPCacheMode
+}
+
+func NewHTTPCache(dir string) *HTTPCache {
+	return &HTTPCache{
+		dir:  dir,
+		mode: HTTPCacheRevalidate,
+	}
+}
+
+func (c *HTTPCache) WithMode(mode HTTPCacheMode) *HTTPCache {
+	c.mode = mode
+
+	return c
+}
+
+type httpCacheEntry struct {
+	Method   string      `json:"method"`
+	URL      string      `json:"url"`
+	Header   http.Header `json:"header"`
+	StoredAt time.Time   `json:"stored_at"`
+
+	data []byte
+}
+
+// setConditions makes the request conditional on the cached response having changed.
+func (e *httpCacheEntry) setConditions(req *http.Request) {
+	if etag := e.Header.Get("ETag"); etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+
+	if lastModified := e.Header.Get("Last-Modified"); lastModified != "" {
+		req.Header.Set("If-Modified-Since", lastModified)
+	}
+}
+
+func (c *HTTPCache) key(method string, url string, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(method + " " + url + "\n"))
+	hash.Write(body)
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func (c *HTTPCache) path(key string) string {
+	return filepath.Join(c.dir, key+".cache")
+}
+
+// load returns the cached response of the key, nil if nothing is cached.
+func (c *HTTPCache) load(key string) (*httpCacheEntry, error) {
+	content, err := os.ReadFile(c.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("could not read cached response: %w", err)
+	}
+
+	metadata, data, found := bytes.Cut(content, []byte("\n"))
+	if !found {
+		return nil, fmt.Errorf("cached response %s is corrupted", c.path(key))
+	}
+
+	entry := &httpCacheEntry{}
+	if err := json.Unmarshal(metadata, entry); err != nil {
+		return nil, fmt.Errorf("could not decode cached response %s: %w", c.path(key), err)
+	}
+
+	entry.data = data
+
+	return entry, nil
+}
+
+// store replaces the cached response of the key, the file is renamed into place so that a response is never read partially.
+func (c *HTTPCache) store(key string, entry *httpCacheEntry) error {
+	metadata, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("could not encode response to cache: %w", err)
+	}
+
+	if err := os.MkdirAll(c.dir, defaultCacheDirMode); err != nil {
+		return fmt.Errorf("could not create cache directory: %w", err)
+	}
+
+	file, err := os.CreateTemp(c.dir, key+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("could not create cache file: %w", err)
+	}
+
+	defer os.Remove(file.Name())
+
+	_, err = file.Write(append(append(metadata, '\n'), entry.data...))
+	if errC := file.Close(); err == nil {
+		err = errC
+	}
+
+	if err != nil {
+		return fmt.Errorf("could not write cache file: %w", err)
+	}
+
+	if err := os.Rename(file.Name(), c.path(key)); err != nil {
+		return fmt.Errorf("could not write cache file: %w", err)
+	}
+
+	return nil
+}
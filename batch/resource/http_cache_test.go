@@ -0,0 +1,179 @@
+package resource
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// testCachedServer serves a report which can change, with the validators of its version.
+type testCachedServer struct {
+	mu           sync.Mutex
+	version      int
+	requests     int
+	notModified  int
+	lastModified bool
+}
+
+func (s *testCachedServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests++
+
+	version := strconv.Itoa(s.version)
+	etag := `"v` + version + `"`
+	modified := "Thu, 01 Aug 2024 0" + version + ":00:00 GMT"
+
+	if s.lastModified {
+		w.Header().Set("Last-Modified", modified)
+	} else {
+		w.Header().Set("ETag", etag)
+	}
+
+	if (!s.lastModified && r.Header.Get("If-None-Match") == etag) ||
+		(s.lastModified && r.Header.Get("If-Modified-Since") == modified) {
+		s.notModified++
+		w.WriteHeader(http.StatusNotModified)
+
+		return
+	}
+
+	_, _ = w.Write([]byte(`[{"version":` + version + `}]`))
+}
+
+func (s *testCachedServer) change() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.version++
+}
+
+func Test_HTTPResource_Cache(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name         string
+		mode         HTTPCacheMode
+		lastModified bool
+		// between is run between the first and the second run
+		between     func(s *testCachedServer)
+		expected    string
+		requests    int
+		notModified int
+	}{
+		{
+			name:        "revalidates with etag",
+			mode:        HTTPCacheRevalidate,
+			between:     func(_ *testCachedServer) {},
+			expected:    `[{"version":0}]`,
+			requests:    2,
+			notModified: 1,
+		},
+		{
+			name:         "revalidates with last modified",
+			mode:         HTTPCacheRevalidate,
+			lastModified: true,
+			between:      func(_ *testCachedServer) {},
+			expected:     `[{"version":0}]`,
+			requests:     2,
+			notModified:  1,
+		},
+		{
+			name:     "downloads changed response",
+			mode:     HTTPCacheRevalidate,
+			between:  (*testCachedServer).change,
+			expected: `[{"version":1}]`,
+			requests: 2,
+		},
+		{
+			name:     "serves cached response although changed",
+			mode:     HTTPCachePreferred,
+			between:  (*testCachedServer).change,
+			expected: `[{"version":0}]`,
+			requests: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			server := &testCachedServer{lastModified: tc.lastModified}
+
+			httpServer := httptest.NewServer(server)
+			defer httpServer.Close()
+
+			cache := NewHTTPCache(t.TempDir()).WithMode(tc.mode)
+
+			ctx := context.Background()
+
+			run := func() string {
+				resource := NewHTTPResource(slog.Default(), httpServer.URL+"/report").WithCache(cache)
+
+				if err := resource.Open(ctx); err != nil {
+					t.Fatalf("failed to open resource: %v", err)
+				}
+
+				defer resource.Close(ctx)
+
+				data, err := io.ReadAll(resource)
+				if err != nil {
+					t.Fatalf("failed to read resource: %v", err)
+				}
+
+				return string(data)
+			}
+
+			if data := run(); data != `[{"version":0}]` {
+				t.Fatalf("unexpected data of first run: %s", data)
+			}
+
+			tc.between(server)
+
+			if data := run(); data != tc.expected {
+				t.Fatalf("expected %s, got %s", tc.expected, data)
+			}
+
+			if server.requests != tc.requests || server.notModified != tc.notModified {
+				t.Fatalf("expected %d requests and %d not modified, got %d and %d",
+					tc.requests, tc.notModified, server.requests, server.notModified)
+			}
+		})
+	}
+}
+
+func Test_HTTPResource_Cache_KeyedByBody(t *testing.T) {
+	t.Parallel()
+
+	server := &testCachedServer{}
+
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	cache := NewHTTPCache(t.TempDir()).WithMode(HTTPCachePreferred)
+
+	ctx := context.Background()
+
+	for _, party := range []string{"zhang", "wang", "zhang"} {
+		resource := NewHTTPResource(slog.Default(), httpServer.URL+"/report").
+			WithMethod(http.MethodPost).
+			WithJSONBody(map[string]string{"party": party}).
+			WithCache(cache)
+
+		if err := resource.Open(ctx); err != nil {
+			t.Fatalf("failed to open resource: %v", err)
+		}
+
+		_ = resource.Close(ctx)
+	}
+
+	if server.requests != 2 {
+		t.Fatalf("expected 2 requests, got %d", server.requests)
+	}
+}
@@ -0,0 +1,151 @@
+package resource
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/template"
+
+	"github.com/goccy/go-json"
+
+	"github.com/ivxivx/go-recon/batch"
+)
+
+var templateFuncs = template.FuncMap{
+	"pathEscape": url.PathEscape,
+}
+
+// resolveURL renders the URL template and the query parameters with the params.
+func (r *HTTPResource) resolveURL() (string, error) {
+	rawURL, err := renderTemplate("url", r.url, r.params)
+	if err != nil {
+		return "", err
+	}
+
+	if len(r.query) == 0 {
+		return rawURL, nil
+	}
+
+	query := make(map[string]string, len(r.query))
+
+	for key, value := range r.query {
+		query[key], err = renderTemplate(key, value, r.params)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return setQueryParams(rawURL, query)
+}
+
+func (r *HTTPResource) newRequest(ctx context.Context, rawURL string) (*http.Request, error) {
+	var body io.Reader = http.NoBody
+
+	if r.body != nil {
+		// marshalled for each attempt, as the body is consumed by the previous one
+		data, err := r.marshalBody(rawURL)
+		if err != nil {
+			return nil, err
+		}
+
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, r.method, rawURL, body)
+	if err != nil {
+		return nil, fmt.Errorf("could not create request for %s: %w", rawURL, err)
+	}
+
+	if r.body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	return req, nil
+}
+
+// marshalBody returns the request body as JSON, its string values rendered with the params, nil if there is no body.
+func (r *HTTPResource) marshalBody(rawURL string) ([]byte, error) {
+	if r.body == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(r.body)
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal request body for %s: %w", rawURL, err)
+	}
+
+	if !bytes.Contains(data, []byte("{{")) {
+		return data, nil
+	}
+
+	// rendered value by value, so that a param is escaped as a JSON string, numbers are kept as they are
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value any
+
+	err = decoder.Decode(&value)
+	if err != nil {
+		return nil, fmt.Errorf("could not unmarshal request body for %s: %w", rawURL, err)
+	}
+
+	value, err = renderJSONValue("body", value, r.params)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err = json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal request body for %s: %w", rawURL, err)
+	}
+
+	return data, nil
+}
+
+// renderJSONValue renders the string values of an unmarshalled JSON value, name is the path of the value.
+func renderJSONValue(name string, value any, params map[string]any) (any, error) {
+	var err error
+
+	switch v := value.(type) {
+	case string:
+		return renderTemplate(name, v, params)
+	case map[string]any:
+		for key, item := range v {
+			v[key], err = renderJSONValue(name+"."+key, item, params)
+			if err != nil {
+				return nil, err
+			}
+		}
+	case []any:
+		for i, item := range v {
+			v[i], err = renderJSONValue(fmt.Sprintf("%s[%d]", name, i), item, params)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return value, nil
+}
+
+func renderTemplate(name string, text string, params map[string]any) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+
+	tmpl, err := template.New(name).Option("missingkey=error").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return "", &batch.IllegalArgumentError{Name: name, Value: text, Err: err}
+	}
+
+	var buf strings.Builder
+	if err := tmpl.Execute(&buf, params); err != nil {
+		return "", fmt.Errorf("could not render %s: %w", name, err)
+	}
+
+	return buf.String(), nil
+}
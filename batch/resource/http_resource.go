@@ -8,23 +8,38 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/ivxivx/go-recon/batch"
+	"github.com/ivxivx/go-recon/batch/retry"
 )
 
-const defaultReqTimeout = 10 * time.Second
+const (
+	defaultReqTimeout = 10 * time.Second
+	maxDrainBytes     = 4 << 10
+)
 
 type HTTPResource struct {
 	logger         *slog.Logger
 	httpClient     *http.Client
 	url            string
+	method         string
+	body           any
+	query          map[string]string
+	params         map[string]any
 	requestTimeout time.Duration
+	retryPolicy    retry.Policy
+	streaming      bool
+	idleTimeout    time.Duration
+	cache          *HTTPCache
 
 	openOnce sync.Once
 
 	reader io.Reader
+	size   int64
+	ctx    context.Context // bounds reads, which have no context
 }
 
 func NewHTTPResource(
@@ -39,6 +54,43 @@ func NewHTTPResourceWithHeaders(
 	url string,
 	headers map[string]string,
 ) *HTTPResource {
+	return NewHTTPResourceWithTransport(logger, url, headers, http.DefaultTransport)
+}
+
+// NewHTTPResourceWithTLS connects to the server with the TLS config, e.g. with a client certificate and a private CA.
+func NewHTTPResourceWithTLS(
+	logger *slog.Logger,
+	url string,
+	headers map[string]string,
+	tlsConfig *TLSConfig,
+) (*HTTPResource, error) {
+	transport, err := NewTLSTransport(tlsConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewHTTPResourceWithTransport(logger, url, headers, transport), nil
+}
+
+// NewHTTPResourceWithTransport sends requests via the given transport, e.g. an OAuth2RoundTripper.
+func NewHTTPResourceWithTransport(
+	logger *slog.Logger,
+	url string,
+	headers map[string]string,
+	transport http.RoundTripper,
+) *HTTPResource {
+	return &HTTPResource{
+		logger:         logger,
+		httpClient:     newHTTPClient(headers, transport),
+		url:            url,
+		method:         http.MethodGet,
+		requestTimeout: defaultReqTimeout,
+		retryPolicy:    retry.Policy{MaxAttempts: 1},
+	}
+}
+
+// newHTTPClient sends requests with the headers via the transport.
+func newHTTPClient(headers map[string]string, transport http.RoundTripper) *http.Client {
 	var hdrs map[string]string
 	if headers == nil {
 		hdrs = make(map[string]string)
@@ -46,16 +98,11 @@ func NewHTTPResourceWithHeaders(
 		hdrs = headers
 	}
 
-	return &HTTPResource{
-		logger: logger,
-		httpClient: &http.Client{
-			Transport: &HTTPInterceptor{
-				Headers:  hdrs,
-				Delegate: http.DefaultTransport,
-			},
+	return &http.Client{
+		Transport: &HTTPInterceptor{
+			Headers:  hdrs,
+			Delegate: transport,
 		},
-		url:            url,
-		requestTimeout: defaultReqTimeout,
 	}
 }
 
@@ -65,15 +112,81 @@ func (r *HTTPResource) WithRequestTimeout(timeout time.Duration) *HTTPResource {
 	return r
 }
 
+func (r *HTTPResource) WithMethod(method string) *HTTPResource {
+	r.method = method
+
+	return r
+}
+
+// WithJSONBody sends the body marshalled as JSON, e.g. a filter of a report API. String values may be templates
+// filled by the params, as in the query.
+func (r *HTTPResource) WithJSONBody(body any) *HTTPResource {
+	r.body = body
+
+	return r
+}
+
+// WithQuery adds query parameters to the URL, values may be templates filled by the params, e.g. {{.start.Format "2006-01-02"}}.
+func (r *HTTPResource) WithQuery(query map[string]string) *HTTPResource {
+	r.query = query
+
+	return r
+}
+
+// WithParams sets the run parameters, e.g. the start and end of the recon period, which fill the URL, query and body templates.
+// Values in the path of the URL can be escaped with pathEscape, e.g. {{pathEscape .account}}.
+func (r *HTTPResource) WithParams(params map[string]any) *HTTPResource {
+	r.params = params
+
+	return r
+}
+
+// WithStreaming reads the response body as it arrives instead of downloading it in Open, e.g. for a large export.
+// The request timeout applies to receiving the response headers, the idle timeout to each read of the body.
+// The request lasts until the resource is closed.
+func (r *HTTPResource) WithStreaming(idleTimeout time.Duration) *HTTPResource {
+	r.streaming = true
+	r.idleTimeout = idleTimeout
+
+	return r
+}
+
+// WithRetry retries failed requests, e.g. 429 or 503, the request timeout applies to each attempt.
+func (r *HTTPResource) WithRetry(policy retry.Policy) *HTTPResource {
+	r.retryPolicy = policy
+
+	return r
+}
+
+// WithCache keeps responses in the cache, so that a rerun can be served from it, see HTTPCacheMode.
+// It is not used in streaming mode.
+func (r *HTTPResource) WithCache(cache *HTTPCache) *HTTPResource {
+	r.cache = cache
+
+	return r
+}
+
 var (
 	_ batch.Resource = (*HTTPResource)(nil)
 	_ io.Reader      = (*HTTPResource)(nil)
+	_ batch.Sizer    = (*HTTPResource)(nil)
 )
 
 func (r *HTTPResource) GetID() string {
 	return r.url
 }
 
+func (r *HTTPResource) Size() int64 {
+	if r.reader == nil {
+		return -1
+	}
+
+	return r.size
+}
+
+// Open downloads the whole response body within the request timeout, or only receives the response headers in streaming mode.
+// Subsequent reads fail once ctx is done.
+// Failed attempts are retried according to the retry policy, as long as ctx is not done.
 func (r *HTTPResource) Open(ctx context.Context) error {
 	if r.reader != nil {
 		r.logger.Warn("resource is already opened", slog.String("resource", r.url))
@@ -84,46 +197,295 @@ func (r *HTTPResource) Open(ctx context.Context) error {
 	var errR error
 
 	r.openOnce.Do(func() {
-		ctxt, cancel := context.WithTimeout(ctx, r.requestTimeout)
-		defer cancel()
-
-		req, err := http.NewRequestWithContext(ctxt, http.MethodGet, r.url, http.NoBody)
+		url, err := r.resolveURL()
 		if err != nil {
-			errR = fmt.Errorf("could not create request for %s: %w", r.url, err)
+			errR = err
 
 			return
 		}
 
-		res, err := r.httpClient.Do(req)
-		if err != nil {
-			errR = &batch.ConnectionError{Operation: batch.ConnOpen, Address: r.url, Err: err}
-
-			return
-		}
+		if r.streaming {
+			stream, size, err := r.openStream(ctx, url)
+			if err != nil {
+				errR = err
 
-		defer res.Body.Close()
+				return
+			}
 
-		if res.StatusCode >= http.StatusMultipleChoices {
-			errR = &batch.InvalidStatusError{StatusCode: res.StatusCode}
+			r.size = size
+			r.ctx = ctx
+			r.reader = stream
 
 			return
 		}
 
-		data, err := io.ReadAll(res.Body)
+		data, _, size, err := r.fetch(ctx, url)
 		if err != nil {
-			errR = &batch.IoError{Operation: batch.IoRead, Resource: r.url, Err: err}
+			errR = err
 
 			return
 		}
 
-		reader := bytes.NewReader(data)
-
-		r.reader = reader
+		r.size = size
+		r.ctx = ctx
+		r.reader = bytes.NewReader(data)
 	})
 
+	if errR != nil {
+		// a failed open can be retried
+		r.openOnce = sync.Once{}
+	}
+
 	return errR
 }
 
+// fetch downloads the response body of the url, failed attempts are retried according to the retry policy.
+func (r *HTTPResource) fetch(ctx context.Context, url string) ([]byte, http.Header, int64, error) {
+	var (
+		data    []byte
+		header  http.Header
+		size    int64
+		attempt int
+	)
+
+	cached, err := r.loadCache(url)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	if cached != nil && r.cache.mode == HTTPCachePreferred {
+		r.logger.Info("http response served from cache", slog.String("resource", url), slog.Time("stored_at", cached.StoredAt))
+
+		return cached.data, cached.Header, int64(len(cached.data)), nil
+	}
+
+	err = retry.Do(ctx, r.logger, r.retryPolicy, r.method+" "+url, func(ctx context.Context) error {
+		attempt++
+
+		var err error
+
+		data, header, size, err = r.get(ctx, url, attempt, cached)
+
+		return err
+	})
+
+	return data, header, size, err
+}
+
+// get makes one attempt to download the response body, it returns the body, the headers and the size of the body.
+// The request is conditional if a response is cached, which is returned if the server replies 304.
+func (r *HTTPResource) get(ctx context.Context, url string, attempt int, cached *httpCacheEntry) ([]byte, http.Header, int64, error) {
+	ctxt, cancel := context.WithTimeout(ctx, r.requestTimeout)
+	defer cancel()
+
+	req, err := r.newRequest(ctxt, url)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	if cached != nil {
+		cached.setConditions(req)
+	}
+
+	start := time.Now()
+
+	res, err := r.httpClient.Do(req)
+	if err != nil {
+		r.logger.Info("http request failed",
+			slog.String("resource", url),
+			slog.Int("attempt", attempt),
+			slog.Duration("duration", time.Since(start)),
+			slog.Any("error", err),
+		)
+
+		return nil, nil, 0, &batch.ConnectionError{Operation: batch.ConnOpen, Address: url, Err: err}
+	}
+
+	defer res.Body.Close()
+
+	r.logger.Info("http request completed",
+		slog.String("resource", url),
+		slog.Int("attempt", attempt),
+		slog.Int("status", res.StatusCode),
+		slog.Duration("duration", time.Since(start)),
+	)
+
+	if res.StatusCode == http.StatusNotModified && cached != nil {
+		r.logger.Info("http response not modified, served from cache", slog.String("resource", url), slog.Time("stored_at", cached.StoredAt))
+
+		return cached.data, cached.Header, int64(len(cached.data)), nil
+	}
+
+	if res.StatusCode >= http.StatusMultipleChoices {
+		// drain a little, so that the connection can be reused by the next attempt
+		_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, maxDrainBytes))
+
+		return nil, nil, 0, &batch.InvalidStatusError{
+			StatusCode: res.StatusCode,
+			RetryAfter: parseRetryAfter(res.Header.Get("Retry-After"), time.Now()),
+		}
+	}
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, nil, 0, &batch.IoError{Operation: batch.IoRead, Resource: url, Err: contextError(ctxt, err)}
+	}
+
+	// Content-Length is unknown (-1) for chunked responses, but the whole body is read already
+	size := res.ContentLength
+	if size < 0 {
+		size = int64(len(data))
+	}
+
+	r.storeCache(url, res.Header, data)
+
+	return data, res.Header, size, nil
+}
+
+// loadCache returns the cached response of the url, nil if there is no cache or nothing is cached.
+func (r *HTTPResource) loadCache(url string) (*httpCacheEntry, error) {
+	if r.cache == nil {
+		return nil, nil
+	}
+
+	body, err := r.marshalBody(url)
+	if err != nil {
+		return nil, err
+	}
+
+	cached, err := r.cache.load(r.cache.key(r.method, url, body))
+	if err != nil {
+		// the response is downloaded again, and replaces the unreadable one
+		r.logger.Warn("could not load cached http response", slog.String("resource", url), slog.Any("error", err))
+
+		return nil, nil
+	}
+
+	return cached, nil
+}
+
+// storeCache keeps the response in the cache, a failure does not fail the request, which has succeeded.
+func (r *HTTPResource) storeCache(url string, header http.Header, data []byte) {
+	if r.cache == nil {
+		return
+	}
+
+	body, err := r.marshalBody(url)
+	if err == nil {
+		err = r.cache.store(r.cache.key(r.method, url, body), &httpCacheEntry{
+			Method:   r.method,
+			URL:      url,
+			Header:   header,
+			StoredAt: time.Now(),
+			data:     data,
+		})
+	}
+
+	if err != nil {
+		r.logger.Warn("could not cache http response", slog.String("resource", url), slog.Any("error", err))
+	}
+}
+
+// openStream sends the request until the response headers are received, failed attempts are retried according to the retry policy.
+// It returns the response body and its size, -1 if unknown.
+func (r *HTTPResource) openStream(ctx context.Context, url string) (*httpStream, int64, error) {
+	var (
+		stream  *httpStream
+		size    int64
+		attempt int
+	)
+
+	err := retry.Do(ctx, r.logger, r.retryPolicy, r.method+" "+url, func(ctx context.Context) error {
+		attempt++
+
+		var err error
+
+		stream, size, err = r.stream(ctx, url, attempt)
+
+		return err
+	})
+
+	return stream, size, err
+}
+
+// stream makes one attempt to receive the response headers, the body is left to be read.
+func (r *HTTPResource) stream(ctx context.Context, url string, attempt int) (*httpStream, int64, error) {
+	// the request outlives Open, it is cancelled when the resource is closed
+	reqCtx, cancel := context.WithCancelCause(ctx)
+
+	headerTimer := time.AfterFunc(r.requestTimeout, func() {
+		cancel(fmt.Errorf("no response within %s: %w", r.requestTimeout, context.DeadlineExceeded))
+	})
+
+	req, err := r.newRequest(reqCtx, url)
+	if err != nil {
+		headerTimer.Stop()
+		cancel(nil)
+
+		return nil, 0, err
+	}
+
+	start := time.Now()
+
+	res, err := r.httpClient.Do(req)
+	if !headerTimer.Stop() && err == nil {
+		// the timer fired right after the response was received
+		res.Body.Close()
+
+		err = context.Cause(reqCtx)
+	}
+
+	if err != nil {
+		cancel(nil)
+
+		r.logger.Info("http request failed",
+			slog.String("resource", url),
+			slog.Int("attempt", attempt),
+			slog.Duration("duration", time.Since(start)),
+			slog.Any("error", err),
+		)
+
+		return nil, 0, &batch.ConnectionError{Operation: batch.ConnOpen, Address: url, Err: contextError(reqCtx, err)}
+	}
+
+	r.logger.Info("http request completed",
+		slog.String("resource", url),
+		slog.Int("attempt", attempt),
+		slog.Int("status", res.StatusCode),
+		slog.Duration("duration", time.Since(start)),
+	)
+
+	if res.StatusCode >= http.StatusMultipleChoices {
+		_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, maxDrainBytes))
+		res.Body.Close()
+		cancel(nil)
+
+		return nil, 0, &batch.InvalidStatusError{
+			StatusCode: res.StatusCode,
+			RetryAfter: parseRetryAfter(res.Header.Get("Retry-After"), time.Now()),
+		}
+	}
+
+	return newHTTPStream(reqCtx, cancel, res.Body, r.idleTimeout), res.ContentLength, nil
+}
+
+// parseRetryAfter parses the Retry-After header, which is either delay seconds or an HTTP date.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0)
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		return max(date.Sub(now), 0)
+	}
+
+	return 0
+}
+
 func (r *HTTPResource) Close(_ context.Context) error {
 	if r.reader == nil {
 		r.logger.Info("resource is not opened, skip close", slog.String("resource", r.url))
@@ -131,9 +493,17 @@ func (r *HTTPResource) Close(_ context.Context) error {
 		return nil
 	}
 
+	var errR error
+
+	if stream, cok := r.reader.(*httpStream); cok {
+		if err := stream.Close(); err != nil {
+			errR = &batch.IoError{Operation: batch.IoClose, Resource: r.url, Err: err}
+		}
+	}
+
 	r.reader = nil
 
-	return nil
+	return errR
 }
 
 func (r *HTTPResource) Read(p []byte) (n int, err error) {
@@ -141,11 +511,15 @@ func (r *HTTPResource) Read(p []byte) (n int, err error) {
 		return 0, &batch.IoError{Operation: batch.IoRead, Resource: r.url, Err: ErrResourceNotOpened}
 	}
 
+	if errC := r.ctx.Err(); errC != nil {
+		return 0, &batch.IoError{Operation: batch.IoRead, Resource: r.url, Err: errC}
+	}
+
 	n, err = r.reader.Read(p)
 
 	if err == nil || errors.Is(err, io.EOF) {
 		return n, err
 	}
 
-	return 0, &batch.IoError{Operation: batch.IoRead, Resource: r.url, Err: err}
+	return 0, &batch.IoError{Operation: batch.IoRead, Resource: r.url, Err: contextError(r.ctx, err)}
 }
@@ -0,0 +1,418 @@
+package resource
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ivxivx/go-recon/batch"
+	"github.com/ivxivx/go-recon/batch/retry"
+)
+
+func Test_HTTPResource_Open_SlowServer(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name    string
+		handler http.HandlerFunc
+	}{
+		{
+			name: "stalled before headers",
+			handler: func(_ http.ResponseWriter, r *http.Request) {
+				<-r.Context().Done()
+			},
+		},
+		{
+			name: "stalled in the middle of body",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`[{"id":`))
+				w.(http.Flusher).Flush()
+
+				<-r.Context().Done()
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(tc.handler)
+			defer server.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+
+			resource := NewHTTPResource(slog.Default(), server.URL).WithRequestTimeout(time.Minute)
+
+			done := make(chan error, 1)
+
+			go func() {
+				done <- resource.Open(ctx)
+			}()
+
+			select {
+			case err := <-done:
+				if !errors.Is(err, context.DeadlineExceeded) {
+					t.Fatalf("expected deadline exceeded, got %v", err)
+				}
+			case <-time.After(testTimeout):
+				t.Fatalf("open did not return after the deadline")
+			}
+		})
+	}
+}
+
+func Test_HTTPResource_Read_Canceled(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	resource := NewHTTPResource(slog.Default(), server.URL)
+
+	if err := resource.Open(ctx); err != nil {
+		t.Fatalf("failed to open resource: %v", err)
+	}
+
+	cancel()
+
+	_, err := resource.Read(make([]byte, 10))
+
+	var ioErr *batch.IoError
+	if !errors.As(err, &ioErr) || !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected canceled read error, got %v", err)
+	}
+}
+
+func Test_HTTPResource_Open_Retry(t *testing.T) {
+	t.Parallel()
+
+	policy := retry.Policy{MaxAttempts: 3, InitialInterval: time.Millisecond, Multiplier: 2}
+
+	testCases := []struct {
+		name       string
+		retryAfter string
+		timeout    time.Duration
+		attempts   int32
+		minElapsed time.Duration
+		status     int
+	}{
+		{
+			name:       "honors retry after",
+			retryAfter: "1",
+			timeout:    testTimeout,
+			attempts:   2,
+			minElapsed: time.Second,
+			status:     http.StatusOK,
+		},
+		{
+			name:       "retry after beyond deadline",
+			retryAfter: "60",
+			timeout:    time.Second,
+			attempts:   1,
+			status:     http.StatusTooManyRequests,
+		},
+		{
+			name:     "backoff without retry after",
+			timeout:  testTimeout,
+			attempts: 2,
+			status:   http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var attempts atomic.Int32
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				if attempts.Add(1) == 1 {
+					if tc.retryAfter != "" {
+						w.Header().Set("Retry-After", tc.retryAfter)
+					}
+
+					w.WriteHeader(http.StatusTooManyRequests)
+
+					return
+				}
+
+				_, _ = w.Write([]byte(`[]`))
+			}))
+			defer server.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), tc.timeout)
+			defer cancel()
+
+			resource := NewHTTPResource(slog.Default(), server.URL).WithRetry(policy)
+
+			start := time.Now()
+
+			err := resource.Open(ctx)
+
+			if elapsed := time.Since(start); elapsed < tc.minElapsed {
+				t.Fatalf("expected to wait at least %s, waited %s", tc.minElapsed, elapsed)
+			}
+
+			if attempts.Load() != tc.attempts {
+				t.Fatalf("expected %d attempts, got %d", tc.attempts, attempts.Load())
+			}
+
+			if tc.status == http.StatusOK {
+				if err != nil {
+					t.Fatalf("failed to open resource: %v", err)
+				}
+
+				return
+			}
+
+			var statusErr *batch.InvalidStatusError
+			if !errors.As(err, &statusErr) || statusErr.StatusCode != tc.status {
+				t.Fatalf("expected status %d, got %v", tc.status, err)
+			}
+		})
+	}
+}
+
+func Test_parseRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 8, 1, 10, 0, 0, 0, time.UTC)
+
+	testCases := map[string]time.Duration{
+		"":                              0,
+		"120":                           2 * time.Minute,
+		"-1":                            0,
+		"Thu, 01 Aug 2024 10:00:30 GMT": 30 * time.Second,
+		"Thu, 01 Aug 2024 09:00:00 GMT": 0,
+		"soon":                          0,
+	}
+
+	for value, expected := range testCases {
+		if actual := parseRetryAfter(value, now); actual != expected {
+			t.Fatalf("expected %s for %q, got %s", expected, value, actual)
+		}
+	}
+}
+
+func Test_HTTPResource_Streaming(t *testing.T) {
+	t.Parallel()
+
+	const chunks = 5
+
+	testCases := []struct {
+		name  string
+		stall bool
+	}{
+		{
+			name: "outlives request timeout",
+		},
+		{
+			name:  "stalled in the middle of body",
+			stall: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				for i := 0; i < chunks; i++ {
+					_, _ = w.Write([]byte("0123456789"))
+					w.(http.Flusher).Flush()
+
+					if tc.stall {
+						<-r.Context().Done()
+
+						return
+					}
+
+					time.Sleep(50 * time.Millisecond)
+				}
+			}))
+			defer server.Close()
+
+			ctx := context.Background()
+
+			// the whole transfer takes longer than the request timeout, but each chunk arrives within the idle timeout
+			resource := NewHTTPResource(slog.Default(), server.URL).
+				WithRequestTimeout(100 * time.Millisecond).
+				WithStreaming(time.Second)
+
+			if err := resource.Open(ctx); err != nil {
+				t.Fatalf("failed to open resource: %v", err)
+			}
+
+			defer resource.Close(ctx)
+
+			done := make(chan struct{})
+
+			var (
+				data []byte
+				err  error
+			)
+
+			go func() {
+				data, err = io.ReadAll(resource)
+
+				close(done)
+			}()
+
+			select {
+			case <-done:
+			case <-time.After(testTimeout):
+				t.Fatalf("read did not return")
+			}
+
+			if tc.stall {
+				if !errors.Is(err, context.DeadlineExceeded) {
+					t.Fatalf("expected deadline exceeded, got %v", err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("failed to read: %v", err)
+			}
+
+			if len(data) != chunks*10 {
+				t.Fatalf("expected %d bytes, got %d", chunks*10, len(data))
+			}
+		})
+	}
+}
+
+func Test_HTTPResource_Streaming_Close(t *testing.T) {
+	t.Parallel()
+
+	canceled := make(chan struct{})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("0123456789"))
+		w.(http.Flusher).Flush()
+
+		<-r.Context().Done()
+		close(canceled)
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+
+	resource := NewHTTPResource(slog.Default(), server.URL).WithStreaming(time.Minute)
+
+	if err := resource.Open(ctx); err != nil {
+		t.Fatalf("failed to open resource: %v", err)
+	}
+
+	if _, err := resource.Read(make([]byte, 10)); err != nil {
+		t.Fatalf("failed to read: %v", err)
+	}
+
+	if err := resource.Close(ctx); err != nil {
+		t.Fatalf("failed to close resource: %v", err)
+	}
+
+	select {
+	case <-canceled:
+	case <-time.After(testTimeout):
+		t.Fatalf("request was not cancelled on close")
+	}
+}
+
+func Test_HTTPResource_Request(t *testing.T) {
+	t.Parallel()
+
+	type filter struct {
+		Party2ID string `json:"party2_id"`
+		From     string `json:"from"`
+		// not representable as float64
+		AccountID int64 `json:"account_id,omitempty"`
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("expected method %s, got %s", http.MethodPost, r.Method)
+		}
+
+		if r.URL.Path != "/accounts/acc 1/transactions" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+
+		if from, to := r.URL.Query().Get("from"), r.URL.Query().Get("to"); from != "2024-08-01" || to != "2024-08-02T00:00:00Z" {
+			t.Errorf("unexpected period from %s to %s", from, to)
+		}
+
+		if contentType := r.Header.Get("Content-Type"); contentType != "application/json" {
+			t.Errorf("unexpected content type %s", contentType)
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil || string(body) != `{"account_id":1000000000000000001,"from":"2024-08-01","party2_id":"zhang"}` {
+			t.Errorf("unexpected body %s, error: %v", body, err)
+		}
+
+		_, _ = w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+
+	start := time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC)
+
+	resource := NewHTTPResource(slog.Default(), server.URL+"/accounts/{{pathEscape .account}}/transactions").
+		WithMethod(http.MethodPost).
+		WithJSONBody(&filter{Party2ID: "zhang", From: `{{.start.Format "2006-01-02"}}`, AccountID: 1000000000000000001}).
+		WithQuery(map[string]string{
+			"from": `{{.start.Format "2006-01-02"}}`,
+			"to":   `{{.end.Format "2006-01-02T15:04:05Z07:00"}}`,
+		}).
+		WithParams(map[string]any{
+			"account": "acc 1",
+			"start":   start,
+			"end":     start.AddDate(0, 0, 1),
+		})
+
+	if err := resource.Open(ctx); err != nil {
+		t.Fatalf("failed to open resource: %v", err)
+	}
+
+	defer resource.Close(ctx)
+
+	t.Run("missing param", func(t *testing.T) {
+		t.Parallel()
+
+		err := NewHTTPResource(slog.Default(), server.URL+"/accounts/{{.account}}/transactions").Open(ctx)
+		if err == nil || !batch.IsPermanent(err) {
+			t.Fatalf("expected permanent error, got %v", err)
+		}
+	})
+
+	t.Run("invalid template", func(t *testing.T) {
+		t.Parallel()
+
+		for _, resource := range []*HTTPResource{
+			NewHTTPResource(slog.Default(), server.URL+"/accounts/{{.account/transactions"),
+			NewHTTPResource(slog.Default(), server.URL).WithJSONBody(&filter{From: "{{.start"}),
+		} {
+			var argErr *batch.IllegalArgumentError
+
+			err := resource.Open(ctx)
+			if !errors.As(err, &argErr) || argErr.Err == nil {
+				t.Fatalf("expected illegal argument with the parse error, got %v", err)
+			}
+		}
+	})
+}
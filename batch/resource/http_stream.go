@@ -0,0 +1,63 @@
+package resource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// httpStream reads a response body as it arrives, the request is cancelled if no data arrives within the idle timeout.
+type httpStream struct {
+	body      io.ReadCloser
+	ctx       context.Context // of the request
+	cancel    context.CancelCauseFunc
+	timeout   time.Duration
+	idleTimer *time.Timer
+}
+
+func newHTTPStream(ctx context.Context, cancel context.CancelCauseFunc, body io.ReadCloser, idleTimeout time.Duration) *httpStream {
+	stream := &httpStream{
+		body:    body,
+		ctx:     ctx,
+		cancel:  cancel,
+		timeout: idleTimeout,
+	}
+
+	if idleTimeout > 0 {
+		stream.idleTimer = time.AfterFunc(idleTimeout, func() {
+			cancel(fmt.Errorf("no data received within %s: %w", idleTimeout, context.DeadlineExceeded))
+		})
+		stream.idleTimer.Stop()
+	}
+
+	return stream
+}
+
+var _ io.ReadCloser = (*httpStream)(nil)
+
+func (s *httpStream) Read(p []byte) (int, error) {
+	if s.idleTimer != nil {
+		s.idleTimer.Reset(s.timeout)
+		defer s.idleTimer.Stop()
+	}
+
+	n, err := s.body.Read(p)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return n, contextError(s.ctx, err)
+	}
+
+	return n, err
+}
+
+// Close cancels the request, so that the rest of the body is not downloaded.
+func (s *httpStream) Close() error {
+	if s.idleTimer != nil {
+		s.idleTimer.Stop()
+	}
+
+	s.cancel(context.Canceled)
+
+	return s.body.Close()
+}
@@ -0,0 +1,435 @@
+package resource
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"sync"
+	"time"
+
+	"github.com/ivxivx/go-recon/batch"
+	"github.com/ivxivx/go-recon/batch/retry"
+)
+
+const defaultUploadContentType = "application/octet-stream"
+
+var errUploadFinished = errors.New("upload is finished")
+
+// multipartUpload sends the written data as a file part of a multipart form.
+type multipartUpload struct {
+	fieldName string
+	fileName  string
+	fields    map[string]string
+}
+
+// begin writes the fields and the header of the file part, it returns the writer of the file part.
+func (m *multipartUpload) begin(mw *multipart.Writer, contentType string) (io.Writer, error) {
+	for name, value := range m.fields {
+		if err := mw.WriteField(name, value); err != nil {
+			return nil, err
+		}
+	}
+
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", mime.FormatMediaType("form-data", map[string]string{"name": m.fieldName, "filename": m.fileName}))
+	header.Set("Content-Type", contentType)
+
+	return mw.CreatePart(header)
+}
+
+// HTTPUploadResource sends the written data to an HTTP endpoint, e.g. a generated recon report.
+// The data is buffered and sent on Close, or sent as it is written in streaming mode.
+type HTTPUploadResource struct {
+	logger         *slog.Logger
+	httpClient     *http.Client
+	url            string
+	method         string
+	contentType    string
+	multipart      *multipartUpload
+	requestTimeout time.Duration
+	retryPolicy    retry.Policy
+	streaming      bool
+
+	openOnce sync.Once
+
+	writer io.Writer
+	buf    *bytes.Buffer
+	upload *httpUpload // in streaming mode
+	ctx    context.Context
+}
+
+func NewHTTPUploadResource(
+	logger *slog.Logger,
+	url string,
+) *HTTPUploadResource {
+	return NewHTTPUploadResourceWithTransport(logger, url, nil, http.DefaultTransport)
+}
+
+// NewHTTPUploadResourceWithTransport sends the request via the given transport, e.g. an OAuth2RoundTripper.
+func NewHTTPUploadResourceWithTransport(
+	logger *slog.Logger,
+	url string,
+	headers map[string]string,
+	transport http.RoundTripper,
+) *HTTPUploadResource {
+	return &HTTPUploadResource{
+		logger:         logger,
+		httpClient:     newHTTPClient(headers, transport),
+		url:            url,
+		method:         http.MethodPut,
+		contentType:    defaultUploadContentType,
+		requestTimeout: defaultReqTimeout,
+		retryPolicy:    retry.Policy{MaxAttempts: 1},
+	}
+}
+
+// WithMethod sets the method of the request, PUT by default.
+func (r *HTTPUploadResource) WithMethod(method string) *HTTPUploadResource {
+	r.method = method
+
+	return r
+}
+
+// WithContentType sets the content type of the data, e.g. text/csv.
+func (r *HTTPUploadResource) WithContentType(contentType string) *HTTPUploadResource {
+	r.contentType = contentType
+
+	return r
+}
+
+// WithMultipart sends the data as the file of a multipart form, along with the given form fields.
+func (r *HTTPUploadResource) WithMultipart(fieldName string, fileName string, fields map[string]string) *HTTPUploadResource {
+	r.multipart = &multipartUpload{
+		fieldName: fieldName,
+		fileName:  fileName,
+		fields:    fields,
+	}
+
+	return r
+}
+
+// WithRequestTimeout bounds each attempt to send the buffered data, or waiting for the response in streaming mode,
+// within the ctx of Close.
+func (r *HTTPUploadResource) WithRequestTimeout(timeout time.Duration) *HTTPUploadResource {
+	r.requestTimeout = timeout
+
+	return r
+}
+
+// WithRetry retries failed requests, e.g. 429 or 503. It is not used in streaming mode, as the data is not kept.
+func (r *HTTPUploadResource) WithRetry(policy retry.Policy) *HTTPUploadResource {
+	r.retryPolicy = policy
+
+	return r
+}
+
+// WithStreaming sends the data as it is written instead of buffering it, e.g. for a large report.
+// The request lasts from Open until Close. A transport which signs the body, i.e. HMACRoundTripper,
+// rejects a streamed body, as it would have to buffer it.
+func (r *HTTPUploadResource) WithStreaming() *HTTPUploadResource {
+	r.streaming = true
+
+	return r
+}
+
+var (
+	_ batch.Resource = (*HTTPUploadResource)(nil)
+	_ io.Writer      = (*HTTPUploadResource)(nil)
+)
+
+func (r *HTTPUploadResource) GetID() string {
+	return r.url
+}
+
+// Open prepares the upload, or sends the request in streaming mode. Subsequent writes fail once ctx is done,
+// in streaming mode the request is aborted as well.
+func (r *HTTPUploadResource) Open(ctx context.Context) error {
+	if r.writer != nil {
+		r.logger.Warn("resource is already opened", slog.String("resource", r.url))
+
+		return nil
+	}
+
+	if err := ctx.Err(); err != nil {
+		return &batch.IoError{Operation: batch.IoOpen, Resource: r.url, Err: err}
+	}
+
+	var errR error
+
+	r.openOnce.Do(func() {
+		if r.streaming {
+			upload, err := r.openUpload(ctx)
+			if err != nil {
+				errR = err
+
+				return
+			}
+
+			r.upload = upload
+			r.ctx = ctx
+			r.writer = upload
+
+			return
+		}
+
+		r.buf = &bytes.Buffer{}
+		r.ctx = ctx
+		r.writer = r.buf
+	})
+
+	if errR != nil {
+		// a failed open can be retried
+		r.openOnce = sync.Once{}
+	}
+
+	return errR
+}
+
+func (r *HTTPUploadResource) Write(p []byte) (int, error) {
+	if r.writer == nil {
+		return 0, &batch.IoError{Operation: batch.IoWrite, Resource: r.url, Err: ErrResourceNotOpened}
+	}
+
+	if errC := r.ctx.Err(); errC != nil {
+		return 0, &batch.IoError{Operation: batch.IoWrite, Resource: r.url, Err: errC}
+	}
+
+	n, err := r.writer.Write(p)
+	if err != nil {
+		return n, &batch.IoError{Operation: batch.IoWrite, Resource: r.url, Err: contextError(r.ctx, err)}
+	}
+
+	return n, nil
+}
+
+// Close sends the buffered data within ctx, or completes the request in streaming mode.
+// A response other than 2xx is returned as batch.InvalidStatusError. The buffered data is kept if it
+// cannot be sent, so that Close can be retried; a streamed upload is not kept.
+func (r *HTTPUploadResource) Close(ctx context.Context) error {
+	if r.writer == nil {
+		r.logger.Info("resource is not opened, skip close", slog.String("resource", r.url))
+
+		return nil
+	}
+
+	var errR error
+
+	if r.upload != nil {
+		errR = r.upload.finish(ctx, r.requestTimeout)
+	} else if err := r.send(ctx); err != nil {
+		return err
+	}
+
+	r.writer = nil
+	r.buf = nil
+	r.upload = nil
+	r.openOnce = sync.Once{}
+
+	return errR
+}
+
+// send sends the buffered data, failed attempts are retried according to the retry policy.
+func (r *HTTPUploadResource) send(ctx context.Context) error {
+	body, contentType, err := r.encode()
+	if err != nil {
+		return err
+	}
+
+	attempt := 0
+
+	return retry.Do(ctx, r.logger, r.retryPolicy, r.method+" "+r.url, func(ctx context.Context) error {
+		attempt++
+
+		ctxt, cancel := context.WithTimeout(ctx, r.requestTimeout)
+		defer cancel()
+
+		req, err := r.newRequest(ctxt, bytes.NewReader(body), contentType)
+		if err != nil {
+			return err
+		}
+
+		return r.do(req, attempt)
+	})
+}
+
+// encode returns the body of the request with the buffered data, and its content type.
+func (r *HTTPUploadResource) encode() ([]byte, string, error) {
+	if r.multipart == nil {
+		return r.buf.Bytes(), r.contentType, nil
+	}
+
+	var body bytes.Buffer
+
+	mw := multipart.NewWriter(&body)
+
+	part, err := r.multipart.begin(mw, r.contentType)
+	if err == nil {
+		_, err = part.Write(r.buf.Bytes())
+	}
+
+	if err == nil {
+		err = mw.Close()
+	}
+
+	if err != nil {
+		return nil, "", fmt.Errorf("could not encode multipart body for %s: %w", r.url, err)
+	}
+
+	return body.Bytes(), mw.FormDataContentType(), nil
+}
+
+func (r *HTTPUploadResource) newRequest(ctx context.Context, body io.Reader, contentType string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, r.method, r.url, body)
+	if err != nil {
+		return nil, fmt.Errorf("could not create request for %s: %w", r.url, err)
+	}
+
+	req.Header.Set("Content-Type", contentType)
+
+	return req, nil
+}
+
+// do sends the request, a response other than 2xx is an error.
+func (r *HTTPUploadResource) do(req *http.Request, attempt int) error {
+	start := time.Now()
+
+	res, err := r.httpClient.Do(req)
+	if err != nil {
+		r.logger.Info("http upload failed",
+			slog.String("resource", r.url),
+			slog.Int("attempt", attempt),
+			slog.Duration("duration", time.Since(start)),
+			slog.Any("error", err),
+		)
+
+		return &batch.ConnectionError{Operation: batch.ConnOpen, Address: r.url, Err: contextError(req.Context(), err)}
+	}
+
+	defer res.Body.Close()
+
+	// drain a little, so that the connection can be reused
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, maxDrainBytes))
+
+	r.logger.Info("http upload completed",
+		slog.String("resource", r.url),
+		slog.Int("attempt", attempt),
+		slog.Int("status", res.StatusCode),
+		slog.Duration("duration", time.Since(start)),
+	)
+
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		return &batch.InvalidStatusError{
+			StatusCode: res.StatusCode,
+			RetryAfter: parseRetryAfter(res.Header.Get("Retry-After"), time.Now()),
+		}
+	}
+
+	return nil
+}
+
+// openUpload sends the request with a body which is written as the data is written.
+func (r *HTTPUploadResource) openUpload(ctx context.Context) (*httpUpload, error) {
+	// the request outlives Open, it is cancelled if the upload is not finished in time
+	reqCtx, cancel := context.WithCancelCause(ctx)
+	pr, pw := io.Pipe()
+
+	contentType := r.contentType
+
+	var mw *multipart.Writer
+	if r.multipart != nil {
+		mw = multipart.NewWriter(pw)
+		contentType = mw.FormDataContentType()
+	}
+
+	req, err := r.newRequest(reqCtx, pr, contentType)
+	if err != nil {
+		cancel(nil)
+
+		return nil, err
+	}
+
+	upload := &httpUpload{
+		body:   pw,
+		part:   pw,
+		mw:     mw,
+		cancel: cancel,
+		done:   make(chan error, 1),
+	}
+
+	go func() {
+		err := r.do(req, 1)
+		if err != nil {
+			// unblocks writes if the request fails before the data is written
+			pr.CloseWithError(err)
+		} else {
+			pr.CloseWithError(errUploadFinished)
+		}
+
+		upload.done <- err
+	}()
+
+	if mw != nil {
+		// blocks until the request is sent, or fails
+		part, err := r.multipart.begin(mw, r.contentType)
+		if err != nil {
+			// the request has failed, its error is the cause
+			errR := <-upload.done
+			cancel(nil)
+
+			return nil, errR
+		}
+
+		upload.part = part
+	}
+
+	return upload, nil
+}
+
+// httpUpload is the body of a request in flight, the response is received once the body is closed.
+type httpUpload struct {
+	body   *io.PipeWriter
+	part   io.Writer // the body, or the file part of the multipart body
+	mw     *multipart.Writer
+	cancel context.CancelCauseFunc
+	done   chan error
+}
+
+var _ io.Writer = (*httpUpload)(nil)
+
+func (u *httpUpload) Write(p []byte) (int, error) {
+	return u.part.Write(p)
+}
+
+// finish closes the body and waits for the response within ctx and the timeout.
+func (u *httpUpload) finish(ctx context.Context, timeout time.Duration) error {
+	defer u.cancel(nil)
+
+	timer := time.AfterFunc(timeout, func() {
+		u.cancel(fmt.Errorf("no response within %s: %w", timeout, context.DeadlineExceeded))
+	})
+	defer timer.Stop()
+
+	stop := context.AfterFunc(ctx, func() {
+		u.cancel(context.Cause(ctx))
+	})
+	defer stop()
+
+	if u.mw != nil {
+		if err := u.mw.Close(); err != nil {
+			u.body.CloseWithError(err)
+
+			return <-u.done
+		}
+	}
+
+	_ = u.body.Close()
+
+	return <-u.done
+}
@@ -0,0 +1,282 @@
+package resource
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ivxivx/go-recon/batch"
+)
+
+func Test_HTTPUploadResource(t *testing.T) {
+	t.Parallel()
+
+	const report = "id,amount\n1,500.00\n2,12.30\n"
+
+	testCases := []struct {
+		name      string
+		multipart bool
+		streaming bool
+		status    int
+	}{
+		{
+			name: "raw",
+		},
+		{
+			name:      "multipart",
+			multipart: true,
+		},
+		{
+			name:      "raw streaming",
+			streaming: true,
+		},
+		{
+			name:      "multipart streaming",
+			multipart: true,
+			streaming: true,
+		},
+		{
+			name:   "rejected",
+			status: http.StatusUnprocessableEntity,
+		},
+		{
+			name:      "rejected streaming",
+			streaming: true,
+			status:    http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("X-Api-Key") != "key" {
+					t.Errorf("expected header of interceptor")
+				}
+
+				var (
+					data []byte
+					err  error
+				)
+
+				if tc.multipart {
+					if r.Method != http.MethodPost {
+						t.Errorf("expected POST, got %s", r.Method)
+					}
+
+					if value := r.FormValue("date"); value != "2024-08-01" {
+						t.Errorf("expected form field, got %s", value)
+					}
+
+					file, header, errF := r.FormFile("report")
+					if errF != nil {
+						t.Errorf("expected file part: %v", errF)
+						w.WriteHeader(http.StatusBadRequest)
+
+						return
+					}
+
+					defer file.Close()
+
+					if header.Filename != "report.csv" || header.Header.Get("Content-Type") != "text/csv" {
+						t.Errorf("unexpected file part %s, %s", header.Filename, header.Header.Get("Content-Type"))
+					}
+
+					data, err = io.ReadAll(file)
+				} else {
+					if r.Method != http.MethodPut || r.Header.Get("Content-Type") != "text/csv" {
+						t.Errorf("unexpected request %s, %s", r.Method, r.Header.Get("Content-Type"))
+					}
+
+					data, err = io.ReadAll(r.Body)
+				}
+
+				if err != nil || string(data) != report {
+					t.Errorf("unexpected data %q, error: %v", data, err)
+				}
+
+				if tc.status != 0 {
+					w.WriteHeader(tc.status)
+				}
+			}))
+			defer server.Close()
+
+			resource := NewHTTPUploadResourceWithTransport(slog.Default(), server.URL+"/reports",
+				map[string]string{"X-Api-Key": "key"}, http.DefaultTransport).
+				WithContentType("text/csv")
+
+			if tc.multipart {
+				resource = resource.WithMethod(http.MethodPost).
+					WithMultipart("report", "report.csv", map[string]string{"date": "2024-08-01"})
+			}
+
+			if tc.streaming {
+				resource = resource.WithStreaming()
+			}
+
+			ctx := context.Background()
+
+			// a closed resource can be opened again
+			for range 2 {
+				if err := resource.Open(ctx); err != nil {
+					t.Fatalf("failed to open resource: %v", err)
+				}
+
+				for _, line := range []string{"id,amount\n", "1,500.00\n", "2,12.30\n"} {
+					if _, err := resource.Write([]byte(line)); err != nil {
+						t.Fatalf("failed to write: %v", err)
+					}
+				}
+
+				err := resource.Close(ctx)
+
+				if tc.status != 0 {
+					var statusErr *batch.InvalidStatusError
+					if !errors.As(err, &statusErr) || statusErr.StatusCode != tc.status {
+						t.Fatalf("expected invalid status %d, got %v", tc.status, err)
+					}
+
+					if tc.streaming {
+						continue
+					}
+
+					// the buffered data is kept, the server checks it is sent again as it is
+					if err := resource.Close(ctx); !errors.As(err, &statusErr) {
+						t.Fatalf("expected invalid status of the retried close, got %v", err)
+					}
+
+					return
+				}
+
+				if err != nil {
+					t.Fatalf("failed to close resource: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func Test_HTTPUploadResource_StreamingSigned(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {
+		t.Errorf("a streamed body must not be sent signed")
+	}))
+	defer server.Close()
+
+	canonicalizer, err := NewTemplateCanonicalizer("{{.Method}}\n{{.Body}}")
+	if err != nil {
+		t.Fatalf("failed to create canonicalizer: %v", err)
+	}
+
+	transport, err := NewHMACRoundTripper(&HMACConfig{Secret: []byte("secret"), SignatureHeader: "X-Signature"}, canonicalizer, http.DefaultTransport)
+	if err != nil {
+		t.Fatalf("failed to create round tripper: %v", err)
+	}
+
+	resource := NewHTTPUploadResourceWithTransport(slog.Default(), server.URL+"/reports", nil, transport).WithStreaming()
+
+	ctx := context.Background()
+
+	if err := resource.Open(ctx); err != nil {
+		t.Fatalf("failed to open resource: %v", err)
+	}
+
+	_, errW := resource.Write([]byte("id,amount\n"))
+
+	errC := resource.Close(ctx)
+
+	if !errors.Is(errW, ErrStreamedBody) && !errors.Is(errC, ErrStreamedBody) {
+		t.Fatalf("expected streamed body rejected, got %v and %v", errW, errC)
+	}
+}
+
+func Test_HTTPUploadResource_CloseContext(t *testing.T) {
+	t.Parallel()
+
+	t.Run("buffered", func(t *testing.T) {
+		t.Parallel()
+
+		var received atomic.Value
+
+		server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			data, _ := io.ReadAll(r.Body)
+			received.Store(string(data))
+		}))
+		t.Cleanup(server.Close)
+
+		openCtx, cancel := context.WithCancel(context.Background())
+
+		resource := NewHTTPUploadResource(slog.Default(), server.URL+"/reports")
+
+		if err := resource.Open(openCtx); err != nil {
+			t.Fatalf("failed to open resource: %v", err)
+		}
+
+		if _, err := resource.Write([]byte("id,amount\n")); err != nil {
+			t.Fatalf("failed to write: %v", err)
+		}
+
+		// the ctx of Open does not bound sending
+		cancel()
+
+		canceled, cancelClose := context.WithCancel(context.Background())
+		cancelClose()
+
+		if err := resource.Close(canceled); !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected canceled, got %v", err)
+		}
+
+		// the data is kept for the retried close
+		if err := resource.Close(context.Background()); err != nil {
+			t.Fatalf("failed to close resource: %v", err)
+		}
+
+		if data, _ := received.Load().(string); data != "id,amount\n" {
+			t.Fatalf("unexpected data %q", data)
+		}
+	})
+
+	t.Run("streaming", func(t *testing.T) {
+		t.Parallel()
+
+		release := make(chan struct{})
+
+		server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			_, _ = io.ReadAll(r.Body)
+
+			// no response until the test is done
+			<-release
+		}))
+		t.Cleanup(server.Close)
+		t.Cleanup(func() { close(release) })
+
+		ctx := context.Background()
+
+		resource := NewHTTPUploadResource(slog.Default(), server.URL+"/reports").WithStreaming().WithRequestTimeout(time.Minute)
+
+		if err := resource.Open(ctx); err != nil {
+			t.Fatalf("failed to open resource: %v", err)
+		}
+
+		if _, err := resource.Write([]byte("id,amount\n")); err != nil {
+			t.Fatalf("failed to write: %v", err)
+		}
+
+		closeCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+
+		if err := resource.Close(closeCtx); !errors.Is(err, context.DeadlineExceeded) || time.Since(start) > testTimeout {
+			t.Fatalf("expected deadline exceeded of close, got %v after %s", err, time.Since(start))
+		}
+	})
+}
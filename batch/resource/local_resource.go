@@ -26,6 +26,8 @@ type LocalResource struct {
 	closeOnce sync.Once
 
 	file *os.File
+	size int64
+	ctx  context.Context // bounds reads and writes, which have no context
 }
 
 func NewLocalResource(
@@ -68,13 +70,23 @@ var (
 	_ batch.Resource = (*LocalResource)(nil)
 	_ io.Reader      = (*LocalResource)(nil)
 	_ io.Writer      = (*LocalResource)(nil)
+	_ batch.Sizer    = (*LocalResource)(nil)
 )
 
 func (r *LocalResource) GetID() string {
 	return r.FilePath
 }
 
-func (r *LocalResource) Open(_ context.Context) error {
+func (r *LocalResource) Size() int64 {
+	if r.file == nil {
+		return -1
+	}
+
+	return r.size
+}
+
+// Open opens the file, subsequent reads and writes fail once ctx is done.
+func (r *LocalResource) Open(ctx context.Context) error {
 	if r.file != nil {
 		// one resource may be shared by multiple readers/writers, so can be opened multiple times
 		r.Logger.Warn("resource is already opened", slog.String("resource", r.FilePath))
@@ -85,6 +97,12 @@ func (r *LocalResource) Open(_ context.Context) error {
 	var errR error
 
 	r.openOnce.Do(func() {
+		if err := ctx.Err(); err != nil {
+			errR = &batch.IoError{Operation: batch.IoOpen, Resource: r.FilePath, Err: err}
+
+			return
+		}
+
 		file, err := os.OpenFile(r.FilePath, r.Flag, r.FileMode)
 		if err != nil {
 			errR = &batch.IoError{Operation: batch.IoOpen, Resource: r.FilePath, Err: err}
@@ -92,12 +110,25 @@ func (r *LocalResource) Open(_ context.Context) error {
 			return
 		}
 
+		r.size = -1
+
+		if info, errS := file.Stat(); errS == nil {
+			r.size = info.Size()
+		}
+
+		r.ctx = ctx
 		r.file = file
 	})
 
+	if errR != nil {
+		// a failed open can be retried
+		r.openOnce = sync.Once{}
+	}
+
 	return errR
 }
 
+// Close releases the file even if ctx is done, as closing a local file does not block.
 func (r *LocalResource) Close(_ context.Context) error {
 	if r.file == nil {
 		r.Logger.Info("resource is not opened, skip close", slog.String("resource", r.FilePath))
@@ -123,6 +154,10 @@ func (r *LocalResource) Read(p []byte) (n int, err error) {
 		return 0, &batch.IoError{Operation: batch.IoRead, Resource: r.FilePath, Err: ErrResourceNotOpened}
 	}
 
+	if errC := r.ctx.Err(); errC != nil {
+		return 0, &batch.IoError{Operation: batch.IoRead, Resource: r.FilePath, Err: errC}
+	}
+
 	n, err = r.file.Read(p)
 
 	if err == nil || errors.Is(err, io.EOF) {
@@ -137,6 +172,10 @@ func (r *LocalResource) Write(p []byte) (n int, err error) {
 		return 0, &batch.IoError{Operation: batch.IoWrite, Resource: r.FilePath, Err: ErrResourceNotOpened}
 	}
 
+	if errC := r.ctx.Err(); errC != nil {
+		return 0, &batch.IoError{Operation: batch.IoWrite, Resource: r.FilePath, Err: errC}
+	}
+
 	n, err = r.file.Write(p)
 	if err == nil {
 		return n, nil
@@ -0,0 +1,60 @@
+package resource
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ivxivx/go-recon/batch"
+)
+
+func Test_LocalResource_Canceled(t *testing.T) {
+	t.Parallel()
+
+	filePath := filepath.Join(t.TempDir(), "report.csv")
+
+	t.Run("open", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := NewLocalResource(slog.Default(), filePath).WithWriteFlag().WithDefaultFileMode().Open(ctx)
+
+		var ioErr *batch.IoError
+		if !errors.As(err, &ioErr) || ioErr.Operation != batch.IoOpen || !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected canceled open error, got %v", err)
+		}
+	})
+
+	t.Run("write and read", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(context.Background())
+
+		resource := NewLocalResource(slog.Default(), filepath.Join(t.TempDir(), "report.csv")).WithFlag(os.O_RDWR | os.O_CREATE).WithDefaultFileMode()
+
+		if err := resource.Open(ctx); err != nil {
+			t.Fatalf("failed to open resource: %v", err)
+		}
+
+		defer resource.Close(context.Background())
+
+		if _, err := resource.Write([]byte("id,amount\n")); err != nil {
+			t.Fatalf("failed to write: %v", err)
+		}
+
+		cancel()
+
+		if _, err := resource.Write([]byte("1,10\n")); !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected canceled write error, got %v", err)
+		}
+
+		if _, err := resource.Read(make([]byte, 10)); !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected canceled read error, got %v", err)
+		}
+	})
+}
@@ -18,6 +18,7 @@ type MemoryResource struct {
 	openOnce sync.Once
 
 	buf *bytes.Buffer
+	ctx context.Context // bounds reads and writes, which have no context
 }
 
 func NewMemoryResource(
@@ -48,14 +49,20 @@ func (r *MemoryResource) GetID() string {
 	return r.identifier
 }
 
-func (r *MemoryResource) Open(_ context.Context) error {
+// Open opens the resource, subsequent reads and writes fail once ctx is done.
+func (r *MemoryResource) Open(ctx context.Context) error {
 	if r.buf != nil {
 		r.logger.Warn("resource is already opened", slog.String("resource", r.identifier))
 
 		return nil
 	}
 
+	if err := ctx.Err(); err != nil {
+		return &batch.IoError{Operation: batch.IoOpen, Resource: r.identifier, Err: err}
+	}
+
 	r.openOnce.Do(func() {
+		r.ctx = ctx
 		r.buf = bytes.NewBuffer([]byte{})
 	})
 
@@ -79,6 +86,10 @@ func (r *MemoryResource) Read(p []byte) (n int, err error) {
 		return 0, &batch.IoError{Operation: batch.IoRead, Resource: r.identifier, Err: ErrResourceNotOpened}
 	}
 
+	if errC := r.ctx.Err(); errC != nil {
+		return 0, &batch.IoError{Operation: batch.IoRead, Resource: r.identifier, Err: errC}
+	}
+
 	n, err = r.buf.Read(p)
 
 	if err == nil || errors.Is(err, io.EOF) {
@@ -93,6 +104,10 @@ func (r *MemoryResource) Write(p []byte) (n int, err error) {
 		return 0, &batch.IoError{Operation: batch.IoWrite, Resource: r.identifier, Err: ErrResourceNotOpened}
 	}
 
+	if errC := r.ctx.Err(); errC != nil {
+		return 0, &batch.IoError{Operation: batch.IoWrite, Resource: r.identifier, Err: errC}
+	}
+
 	n, err = r.buf.Write(p)
 	if err == nil {
 		return n, nil
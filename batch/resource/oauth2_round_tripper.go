@@ -0,0 +1,250 @@
+package resource
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goccy/go-json"
+
+	"github.com/ivxivx/go-recon/batch"
+)
+
+// a token is refreshed a little before it expires, so that it does not expire in flight
+const defaultTokenExpiryDelta = 10 * time.Second
+
+type OAuth2ClientCredentials struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	// CredentialsInBody sends the client credentials in the form body instead of the basic auth header
+	CredentialsInBody bool
+}
+
+type oauth2Token struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+
+	expiry time.Time // zero if the token does not expire
+}
+
+// OAuth2RoundTripper authorizes requests with a token obtained via the OAuth2 client credentials flow.
+// The token is cached until it expires, and it is refreshed once if a request is rejected with 401.
+type OAuth2RoundTripper struct {
+	logger      *slog.Logger
+	credentials *OAuth2ClientCredentials
+	delegate    http.RoundTripper
+	expiryDelta time.Duration
+	now         func() time.Time
+
+	mu    sync.Mutex
+	token *oauth2Token
+	fetch *tokenFetch // in flight, nil if none
+}
+
+// tokenFetch is a token request shared by the requests which need a new token.
+type tokenFetch struct {
+	done     chan struct{}
+	token    *oauth2Token
+	err      error
+	canceled bool // by the ctx of the request which made it, the others fetch again
+}
+
+func NewOAuth2RoundTripper(
+	logger *slog.Logger,
+	credentials *OAuth2ClientCredentials,
+	delegate http.RoundTripper,
+) *OAuth2RoundTripper {
+	return &OAuth2RoundTripper{
+		logger:      logger,
+		credentials: credentials,
+		delegate:    delegate,
+		expiryDelta: defaultTokenExpiryDelta,
+		now:         time.Now,
+	}
+}
+
+var _ http.RoundTripper = (*OAuth2RoundTripper)(nil)
+
+func (rt *OAuth2RoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := rt.getToken(req.Context(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := rt.delegate.RoundTrip(rt.authorize(req, token))
+	if err != nil || res.StatusCode != http.StatusUnauthorized {
+		return res, err
+	}
+
+	// the token may be revoked before it expires, the request can be retried only if its body can be replayed
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return res, nil
+	}
+
+	rt.logger.Info("request unauthorized, refreshing token", slog.String("url", req.URL.Redacted()))
+
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, maxDrainBytes))
+	res.Body.Close()
+
+	token, err = rt.getToken(req.Context(), token)
+	if err != nil {
+		return nil, err
+	}
+
+	retryReq := rt.authorize(req, token)
+
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, fmt.Errorf("could not replay request body: %w", err)
+		}
+
+		retryReq.Body = body
+	}
+
+	return rt.delegate.RoundTrip(retryReq)
+}
+
+// authorize returns a copy of the request with the token, a round tripper must not modify the request.
+func (rt *OAuth2RoundTripper) authorize(req *http.Request, token *oauth2Token) *http.Request {
+	authorized := req.Clone(req.Context())
+
+	tokenType := token.TokenType
+	if tokenType == "" || strings.EqualFold(tokenType, "bearer") {
+		tokenType = "Bearer"
+	}
+
+	authorized.Header.Set("Authorization", tokenType+" "+token.AccessToken)
+
+	return authorized
+}
+
+// getToken returns the cached token if it is still valid, otherwise it obtains a new one.
+// A rejected token is not reused, unless another request has refreshed it already.
+// The lock is not held while the token is requested, so that requests with a valid token are not blocked,
+// and concurrent requests share a single token request.
+func (rt *OAuth2RoundTripper) getToken(ctx context.Context, rejected *oauth2Token) (*oauth2Token, error) {
+	for {
+		rt.mu.Lock()
+
+		if rt.token != nil && rt.token != rejected &&
+			(rt.token.expiry.IsZero() || rt.now().Add(rt.expiryDelta).Before(rt.token.expiry)) {
+			token := rt.token
+			rt.mu.Unlock()
+
+			return token, nil
+		}
+
+		fetch := rt.fetch
+		if fetch == nil {
+			fetch = &tokenFetch{done: make(chan struct{})}
+			rt.fetch = fetch
+			rt.mu.Unlock()
+
+			return rt.fetchToken(ctx, fetch)
+		}
+
+		rt.mu.Unlock()
+
+		select {
+		case <-fetch.done:
+		case <-ctx.Done():
+			return nil, &batch.ConnectionError{Operation: batch.ConnOpen, Address: rt.credentials.TokenURL, Err: context.Cause(ctx)}
+		}
+
+		if fetch.canceled {
+			continue
+		}
+
+		return fetch.token, fetch.err
+	}
+}
+
+// fetchToken requests a token and shares the outcome with the requests waiting for it.
+func (rt *OAuth2RoundTripper) fetchToken(ctx context.Context, fetch *tokenFetch) (*oauth2Token, error) {
+	token, err := rt.requestToken(ctx)
+	if err != nil {
+		err = &batch.ConnectionError{Operation: batch.ConnOpen, Address: rt.credentials.TokenURL, Err: err}
+	}
+
+	rt.mu.Lock()
+
+	if err == nil {
+		rt.token = token
+	}
+
+	fetch.token, fetch.err, fetch.canceled = token, err, ctx.Err() != nil
+	rt.fetch = nil
+
+	rt.mu.Unlock()
+
+	close(fetch.done)
+
+	return token, err
+}
+
+func (rt *OAuth2RoundTripper) requestToken(ctx context.Context) (*oauth2Token, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+
+	if len(rt.credentials.Scopes) > 0 {
+		form.Set("scope", strings.Join(rt.credentials.Scopes, " "))
+	}
+
+	if rt.credentials.CredentialsInBody {
+		form.Set("client_id", rt.credentials.ClientID)
+		form.Set("client_secret", rt.credentials.ClientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, rt.credentials.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("could not create token request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	if !rt.credentials.CredentialsInBody {
+		req.SetBasicAuth(url.QueryEscape(rt.credentials.ClientID), url.QueryEscape(rt.credentials.ClientSecret))
+	}
+
+	start := rt.now()
+
+	res, err := rt.delegate.RoundTrip(req)
+	if err != nil {
+		return nil, fmt.Errorf("could not request token: %w", err)
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode >= http.StatusMultipleChoices {
+		_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, maxDrainBytes))
+
+		return nil, &batch.InvalidStatusError{StatusCode: res.StatusCode}
+	}
+
+	token := &oauth2Token{}
+	if err := json.NewDecoder(res.Body).Decode(token); err != nil {
+		return nil, fmt.Errorf("could not decode token: %w", err)
+	}
+
+	if token.AccessToken == "" {
+		return nil, fmt.Errorf("token response without access token")
+	}
+
+	if token.ExpiresIn > 0 {
+		token.expiry = start.Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+
+	rt.logger.Info("token obtained", slog.String("token_url", rt.credentials.TokenURL), slog.Time("expiry", token.expiry))
+
+	return token, nil
+}
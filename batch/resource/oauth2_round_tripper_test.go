@@ -0,0 +1,250 @@
+package resource
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ivxivx/go-recon/batch"
+)
+
+// testAuthServer is a token endpoint and an API which accepts only the latest token.
+type testAuthServer struct {
+	t *testing.T
+
+	mu            sync.Mutex
+	tokenRequests int
+	validToken    string
+	tokenStatus   int
+}
+
+func (s *testAuthServer) issueToken(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.tokenStatus != 0 {
+		w.WriteHeader(s.tokenStatus)
+
+		return
+	}
+
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "client_credentials" || r.PostForm.Get("scope") != "reports" {
+		s.t.Errorf("unexpected token request %v, error: %v", r.PostForm, err)
+	}
+
+	if clientID, clientSecret, ok := r.BasicAuth(); !ok || clientID != "recon" || clientSecret != "secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+
+		return
+	}
+
+	s.tokenRequests++
+	s.validToken = fmt.Sprintf("token-%d", s.tokenRequests)
+
+	_, _ = fmt.Fprintf(w, `{"access_token":%q,"token_type":"bearer","expires_in":3600}`, s.validToken)
+}
+
+func (s *testAuthServer) serveAPI(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.Header.Get("Authorization") != "Bearer "+s.validToken {
+		w.WriteHeader(http.StatusUnauthorized)
+
+		return
+	}
+
+	_, _ = w.Write([]byte(`[]`))
+}
+
+// revoke invalidates the issued token before it expires.
+func (s *testAuthServer) revoke() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.validToken = "revoked"
+}
+
+func Test_OAuth2RoundTripper(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name string
+		// between is run between the first and the second request
+		between       func(s *testAuthServer, clock *time.Time)
+		tokenStatus   int
+		tokenRequests int
+		errCode       batch.ErrorCode
+	}{
+		{
+			name:          "caches token",
+			between:       func(_ *testAuthServer, _ *time.Time) {},
+			tokenRequests: 1,
+		},
+		{
+			name: "refreshes expired token",
+			between: func(_ *testAuthServer, clock *time.Time) {
+				*clock = clock.Add(time.Hour)
+			},
+			tokenRequests: 2,
+		},
+		{
+			name: "refreshes revoked token on 401",
+			between: func(s *testAuthServer, _ *time.Time) {
+				s.revoke()
+			},
+			tokenRequests: 2,
+		},
+		{
+			name:        "token endpoint rejects credentials",
+			tokenStatus: http.StatusUnauthorized,
+			errCode:     batch.CodeAuthentication,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			authServer := &testAuthServer{t: t, tokenStatus: tc.tokenStatus}
+
+			mux := http.NewServeMux()
+			mux.HandleFunc("/oauth/token", authServer.issueToken)
+			mux.HandleFunc("/transactions", authServer.serveAPI)
+
+			server := httptest.NewServer(mux)
+			defer server.Close()
+
+			clock := time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC)
+
+			roundTripper := NewOAuth2RoundTripper(slog.Default(), &OAuth2ClientCredentials{
+				TokenURL:     server.URL + "/oauth/token",
+				ClientID:     "recon",
+				ClientSecret: "secret",
+				Scopes:       []string{"reports"},
+			}, http.DefaultTransport)
+			roundTripper.now = func() time.Time { return clock }
+
+			ctx := context.Background()
+
+			open := func() error {
+				resource := NewHTTPResourceWithTransport(slog.Default(), server.URL+"/transactions", nil, roundTripper)
+
+				if err := resource.Open(ctx); err != nil {
+					return err
+				}
+
+				return resource.Close(ctx)
+			}
+
+			err := open()
+
+			if tc.errCode != "" {
+				if code := batch.CodeOf(err); code != tc.errCode || !batch.IsPermanent(err) {
+					t.Fatalf("expected permanent error with code %s, got %s: %v", tc.errCode, code, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("failed to open resource: %v", err)
+			}
+
+			tc.between(authServer, &clock)
+
+			if err := open(); err != nil {
+				t.Fatalf("failed to open resource again: %v", err)
+			}
+
+			if authServer.tokenRequests != tc.tokenRequests {
+				t.Fatalf("expected %d token requests, got %d", tc.tokenRequests, authServer.tokenRequests)
+			}
+		})
+	}
+}
+
+func Test_OAuth2RoundTripper_SlowTokenEndpoint(t *testing.T) {
+	t.Parallel()
+
+	var tokenRequests atomic.Int32
+
+	requested := make(chan struct{}, 10)
+	release := make(chan struct{})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		n := tokenRequests.Add(1)
+		requested <- struct{}{}
+
+		<-release
+
+		_, _ = fmt.Fprintf(w, `{"access_token":"token-%d","expires_in":3600}`, n)
+	}))
+	defer server.Close()
+
+	roundTripper := NewOAuth2RoundTripper(slog.Default(), &OAuth2ClientCredentials{
+		TokenURL:     server.URL,
+		ClientID:     "recon",
+		ClientSecret: "secret",
+	}, http.DefaultTransport)
+
+	ctx := context.Background()
+
+	cached := &oauth2Token{AccessToken: "token-0"}
+	roundTripper.token = cached
+
+	// refreshes the rejected token, all requests which need a new token wait for the same token request
+	tokens := make(chan *oauth2Token, 3)
+
+	for range cap(tokens) {
+		go func() {
+			token, err := roundTripper.getToken(ctx, cached)
+			if err != nil {
+				t.Errorf("failed to get token: %v", err)
+			}
+
+			tokens <- token
+		}()
+	}
+
+	select {
+	case <-requested:
+	case <-time.After(testTimeout):
+		t.Fatalf("token was not requested")
+	}
+
+	// the cached token is still returned without waiting for the token request
+	done := make(chan *oauth2Token, 1)
+
+	go func() {
+		token, _ := roundTripper.getToken(ctx, nil)
+		done <- token
+	}()
+
+	select {
+	case token := <-done:
+		if token != cached {
+			t.Fatalf("expected the cached token, got %v", token)
+		}
+	case <-time.After(testTimeout):
+		t.Fatalf("getting the cached token is blocked by the token request")
+	}
+
+	close(release)
+
+	for range cap(tokens) {
+		if token := <-tokens; token == nil || token.AccessToken != "token-1" {
+			t.Fatalf("expected the refreshed token, got %v", token)
+		}
+	}
+
+	if n := tokenRequests.Load(); n != 1 {
+		t.Fatalf("expected 1 token request, got %d", n)
+	}
+}
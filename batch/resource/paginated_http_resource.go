@@ -0,0 +1,168 @@
+package resource
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+
+	"github.com/ivxivx/go-recon/batch"
+)
+
+// PaginatedHTTPResource fetches the pages of a paginated API one by one, the next page is decided by a paginator.
+// Requests are made as configured in the given HTTP resource, e.g. method, body, headers, timeout and retry.
+type PaginatedHTTPResource struct {
+	logger    *slog.Logger
+	resource  *HTTPResource
+	paginator Paginator
+	maxPages  int
+
+	openOnce sync.Once
+
+	page   *Page
+	reader io.Reader
+	ctx    context.Context // bounds reads, which have no context
+}
+
+func NewPaginatedHTTPResource(logger *slog.Logger, resource *HTTPResource, paginator Paginator) *PaginatedHTTPResource {
+	return &PaginatedHTTPResource{
+		logger:    logger,
+		resource:  resource,
+		paginator: paginator,
+	}
+}
+
+// WithMaxPages stops fetching after the given number of pages, as a safeguard against endless pagination.
+func (r *PaginatedHTTPResource) WithMaxPages(maxPages int) *PaginatedHTTPResource {
+	r.maxPages = maxPages
+
+	return r
+}
+
+var (
+	_ batch.PagedResource = (*PaginatedHTTPResource)(nil)
+	_ io.Reader           = (*PaginatedHTTPResource)(nil)
+)
+
+func (r *PaginatedHTTPResource) GetID() string {
+	return r.resource.GetID()
+}
+
+// Open fetches the first page, subsequent reads fail once ctx is done.
+func (r *PaginatedHTTPResource) Open(ctx context.Context) error {
+	if r.reader != nil {
+		r.logger.Warn("resource is already opened", slog.String("resource", r.GetID()))
+
+		return nil
+	}
+
+	var errR error
+
+	r.openOnce.Do(func() {
+		resourceURL, err := r.resource.resolveURL()
+		if err != nil {
+			errR = err
+
+			return
+		}
+
+		pageURL, err := r.paginator.First(resourceURL)
+		if err != nil {
+			errR = fmt.Errorf("could not get first page of %s: %w", r.GetID(), err)
+
+			return
+		}
+
+		errR = r.fetch(ctx, 1, pageURL)
+		if errR != nil {
+			return
+		}
+
+		r.ctx = ctx
+	})
+
+	if errR != nil {
+		// a failed open can be retried
+		r.openOnce = sync.Once{}
+	}
+
+	return errR
+}
+
+func (r *PaginatedHTTPResource) NextPage(ctx context.Context) error {
+	if r.reader == nil {
+		return &batch.IoError{Operation: batch.IoRead, Resource: r.GetID(), Err: ErrResourceNotOpened}
+	}
+
+	if r.maxPages > 0 && r.page.Number >= r.maxPages {
+		r.logger.Warn("max pages reached", slog.String("resource", r.GetID()), slog.Int("max_pages", r.maxPages))
+
+		return io.EOF
+	}
+
+	pageURL, err := r.paginator.Next(r.page)
+	if err != nil {
+		return &batch.IoError{Operation: batch.IoRead, Resource: r.GetID(), Err: err}
+	}
+
+	if pageURL == "" {
+		return io.EOF
+	}
+
+	// a misbehaving API may return the same page forever
+	if pageURL == r.page.URL {
+		return &batch.IoError{Operation: batch.IoRead, Resource: r.GetID(), Err: fmt.Errorf("next page is the same as page %d: %s", r.page.Number, pageURL)}
+	}
+
+	return r.fetch(ctx, r.page.Number+1, pageURL)
+}
+
+func (r *PaginatedHTTPResource) fetch(ctx context.Context, number int, pageURL string) error {
+	data, header, _, err := r.resource.fetch(ctx, pageURL)
+	if err != nil {
+		return err
+	}
+
+	r.logger.Debug("page fetched", slog.String("resource", r.GetID()), slog.Int("page", number), slog.Int("size", len(data)))
+
+	r.page = &Page{Number: number, URL: pageURL, Header: header, Body: data}
+	r.reader = bytes.NewReader(data)
+
+	return nil
+}
+
+func (r *PaginatedHTTPResource) Close(_ context.Context) error {
+	if r.reader == nil {
+		r.logger.Info("resource is not opened, skip close", slog.String("resource", r.GetID()))
+
+		return nil
+	}
+
+	r.page = nil
+	r.reader = nil
+	r.openOnce = sync.Once{}
+
+	return nil
+}
+
+// Read reads the current page, it returns io.EOF at the end of the page.
+func (r *PaginatedHTTPResource) Read(p []byte) (n int, err error) {
+	if r.reader == nil {
+		return 0, &batch.IoError{Operation: batch.IoRead, Resource: r.GetID(), Err: ErrResourceNotOpened}
+	}
+
+	if errC := r.ctx.Err(); errC != nil {
+		return 0, &batch.IoError{Operation: batch.IoRead, Resource: r.GetID(), Err: errC}
+	}
+
+	n, err = r.reader.Read(p)
+
+	if err == nil || errors.Is(err, io.EOF) {
+		return n, err
+	}
+
+	return 0, &batch.IoError{Operation: batch.IoRead, Resource: r.GetID(), Err: err}
+}
@@ -0,0 +1,115 @@
+package resource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// readTestPages reads the pages of the resource until NextPage returns io.EOF or fails.
+func readTestPages(ctx context.Context, resource *PaginatedHTTPResource) ([]string, error) {
+	var pages []string
+
+	for {
+		data, err := io.ReadAll(resource)
+		if err != nil {
+			return pages, err
+		}
+
+		pages = append(pages, string(data))
+
+		err = resource.NextPage(ctx)
+		if errors.Is(err, io.EOF) {
+			return pages, nil
+		}
+
+		if err != nil {
+			return pages, err
+		}
+	}
+}
+
+func Test_PaginatedHTTPResource(t *testing.T) {
+	t.Parallel()
+
+	// page -> next page, -1 if it is the last page
+	testCases := []struct {
+		name     string
+		next     []int
+		maxPages int
+		expected []string
+		err      bool
+	}{
+		{
+			name:     "all pages",
+			next:     []int{1, 2, -1},
+			expected: []string{"page 0", "page 1", "page 2"},
+		},
+		{
+			name:     "max pages",
+			next:     []int{1, 2, -1},
+			maxPages: 2,
+			expected: []string{"page 0", "page 1"},
+		},
+		{
+			name:     "same page again",
+			next:     []int{1, 1},
+			expected: []string{"page 0", "page 1"},
+			err:      true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				page, _ := strconv.Atoi(r.URL.Query().Get("p"))
+
+				if next := tc.next[page]; next >= 0 {
+					w.Header().Set("Link", fmt.Sprintf(`</?p=%d>; rel="next"`, next))
+				}
+
+				_, _ = w.Write([]byte("page " + strconv.Itoa(page)))
+			}))
+			defer server.Close()
+
+			ctx := context.Background()
+
+			resource := NewPaginatedHTTPResource(slog.Default(), NewHTTPResource(slog.Default(), server.URL), &LinkHeaderPaginator{}).
+				WithMaxPages(tc.maxPages)
+
+			if err := resource.NextPage(ctx); !errors.Is(err, ErrResourceNotOpened) {
+				t.Fatalf("expected not opened, got %v", err)
+			}
+
+			// a closed resource can be opened again
+			for range 2 {
+				if err := resource.Open(ctx); err != nil {
+					t.Fatalf("failed to open resource: %v", err)
+				}
+
+				pages, err := readTestPages(ctx, resource)
+				if tc.err != (err != nil) {
+					t.Fatalf("unexpected error: %v", err)
+				}
+
+				if diff := cmp.Diff(tc.expected, pages); diff != "" {
+					t.Fatalf("unexpected pages (-want +got):\n%s", diff)
+				}
+
+				if err := resource.Close(ctx); err != nil {
+					t.Fatalf("failed to close resource: %v", err)
+				}
+			}
+		})
+	}
+}
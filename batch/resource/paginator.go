@@ -0,0 +1,232 @@
+package resource
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-json"
+)
+
+// Page is a page fetched by a paginated resource.
+type Page struct {
+	Number int // starting from 1
+	URL    string
+	Header http.Header
+	Body   []byte
+}
+
+// Paginator decides which page to fetch next.
+type Paginator interface {
+	// First returns the URL of the first page, given the URL of the resource
+	First(resourceURL string) (string, error)
+	// Next returns the URL of the page after the given one, "" if it is the last page
+	Next(page *Page) (string, error)
+}
+
+var (
+	_ Paginator = (*OffsetPaginator)(nil)
+	_ Paginator = (*CursorPaginator)(nil)
+	_ Paginator = (*LinkHeaderPaginator)(nil)
+)
+
+// OffsetPaginator paginates by page number and page size query parameters, e.g. ?page=2&size=100.
+// A page with less records than the page size is the last page.
+type OffsetPaginator struct {
+	PageParam string
+	SizeParam string
+	FirstPage int // usually 0 or 1
+	PageSize  int
+	// RecordsField is the dot-separated path of the records in the page, e.g. "data.items", "" if the page is an array
+	RecordsField string
+}
+
+func NewOffsetPaginator(pageParam string, sizeParam string, pageSize int) *OffsetPaginator {
+	return &OffsetPaginator{
+		PageParam: pageParam,
+		SizeParam: sizeParam,
+		FirstPage: 1,
+		PageSize:  pageSize,
+	}
+}
+
+func (p *OffsetPaginator) WithFirstPage(firstPage int) *OffsetPaginator {
+	p.FirstPage = firstPage
+
+	return p
+}
+
+func (p *OffsetPaginator) WithRecordsField(field string) *OffsetPaginator {
+	p.RecordsField = field
+
+	return p
+}
+
+func (p *OffsetPaginator) First(resourceURL string) (string, error) {
+	return setQueryParams(resourceURL, map[string]string{
+		p.PageParam: strconv.Itoa(p.FirstPage),
+		p.SizeParam: strconv.Itoa(p.PageSize),
+	})
+}
+
+func (p *OffsetPaginator) Next(page *Page) (string, error) {
+	value, err := lookupJSONField(page.Body, p.RecordsField)
+	if err != nil {
+		return "", err
+	}
+
+	records, cok := value.([]any)
+	if !cok {
+		return "", fmt.Errorf("records field %q of page %d is not an array", p.RecordsField, page.Number)
+	}
+
+	if len(records) < p.PageSize {
+		return "", nil
+	}
+
+	return setQueryParams(page.URL, map[string]string{
+		p.PageParam: strconv.Itoa(p.FirstPage + page.Number),
+	})
+}
+
+// CursorPaginator paginates by a cursor returned in the page, e.g. {"next_cursor": "abc"} leads to ?cursor=abc.
+// A page without cursor is the last page.
+type CursorPaginator struct {
+	// CursorField is the dot-separated path of the cursor in the page, e.g. "meta.next_cursor"
+	CursorField string
+	CursorParam string
+}
+
+func NewCursorPaginator(cursorField string, cursorParam string) *CursorPaginator {
+	return &CursorPaginator{
+		CursorField: cursorField,
+		CursorParam: cursorParam,
+	}
+}
+
+func (p *CursorPaginator) First(resourceURL string) (string, error) {
+	return resourceURL, nil
+}
+
+func (p *CursorPaginator) Next(page *Page) (string, error) {
+	value, err := lookupJSONField(page.Body, p.CursorField)
+	if err != nil {
+		return "", err
+	}
+
+	var cursor string
+
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		cursor = v
+	default:
+		cursor = fmt.Sprint(v)
+	}
+
+	if cursor == "" {
+		return "", nil
+	}
+
+	return setQueryParams(page.URL, map[string]string{p.CursorParam: cursor})
+}
+
+// LinkHeaderPaginator follows the rel="next" link of the Link header (RFC 8288), e.g. as GitHub APIs do.
+// A page without next link is the last page.
+type LinkHeaderPaginator struct{}
+
+func (p *LinkHeaderPaginator) First(resourceURL string) (string, error) {
+	return resourceURL, nil
+}
+
+func (p *LinkHeaderPaginator) Next(page *Page) (string, error) {
+	for _, header := range page.Header.Values("Link") {
+		for _, link := range strings.Split(header, ",") {
+			target, params, found := strings.Cut(strings.TrimSpace(link), ";")
+			if !found || !isNextLink(params) {
+				continue
+			}
+
+			target = strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(target), "<"), ">")
+
+			// the link may be relative to the page
+			base, err := url.Parse(page.URL)
+			if err != nil {
+				return "", fmt.Errorf("could not parse page url %s: %w", page.URL, err)
+			}
+
+			next, err := base.Parse(target)
+			if err != nil {
+				return "", fmt.Errorf("could not parse next link %s: %w", target, err)
+			}
+
+			return next.String(), nil
+		}
+	}
+
+	return "", nil
+}
+
+func isNextLink(params string) bool {
+	for _, param := range strings.Split(params, ";") {
+		name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+		if !strings.EqualFold(name, "rel") {
+			continue
+		}
+
+		for _, rel := range strings.Fields(strings.Trim(value, `"`)) {
+			if strings.EqualFold(rel, "next") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func setQueryParams(rawURL string, params map[string]string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("could not parse url %s: %w", rawURL, err)
+	}
+
+	query := u.Query()
+	for key, value := range params {
+		query.Set(key, value)
+	}
+
+	u.RawQuery = query.Encode()
+
+	return u.String(), nil
+}
+
+// lookupJSONField returns the value at the dot-separated path of a JSON document, nil if it does not exist.
+func lookupJSONField(body []byte, path string) (any, error) {
+	// numbers are kept as they are, e.g. a large numeric cursor
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("could not unmarshal page: %w", err)
+	}
+
+	if path == "" {
+		return value, nil
+	}
+
+	for _, key := range strings.Split(path, ".") {
+		object, cok := value.(map[string]any)
+		if !cok {
+			return nil, nil
+		}
+
+		value = object[key]
+	}
+
+	return value, nil
+}
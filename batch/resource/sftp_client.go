@@ -2,10 +2,14 @@ package resource
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"net"
 	"os"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/ivxivx/go-recon/batch"
 	"github.com/pkg/sftp"
@@ -16,6 +20,7 @@ type ISftpClient interface {
 	Open(ctx context.Context) error
 	Close(ctx context.Context) error
 	OpenFile(path string, flag int) (*sftp.File, error)
+	ReadDir(path string) ([]os.FileInfo, error)
 }
 
 type SSHServer struct {
@@ -35,13 +40,16 @@ func NewSSHServer(
 
 // resource on sftp server
 type SftpClient struct {
-	logger     *slog.Logger
-	destServer *SSHServer
+	logger           *slog.Logger
+	destServer       *SSHServer
+	handshakeTimeout *time.Duration
 
 	openOnce  sync.Once
 	closeOnce sync.Once
 
-	delegate *sftp.Client
+	delegate  *sftp.Client
+	sshClient *ssh.Client
+	stop      func() bool
 }
 
 var _ ISftpClient = (*SftpClient)(nil)
@@ -53,6 +61,14 @@ func NewSftpClient(logger *slog.Logger, destServer *SSHServer) *SftpClient {
 	}
 }
 
+// WithHandshakeTimeout bounds dialing and the ssh handshake, it defaults to the Timeout of the ssh config.
+// Unlike the ctx of Open, it does not bound the connection once it is established.
+func (c *SftpClient) WithHandshakeTimeout(timeout time.Duration) *SftpClient {
+	c.handshakeTimeout = &timeout
+
+	return c
+}
+
 func NewAuthMethodFromPrivateKeyFile(privateKeyFile string) (ssh.AuthMethod, error) {
 	keyData, err := os.ReadFile(privateKeyFile)
 	if err != nil {
@@ -73,7 +89,64 @@ func NewAuthMethodFromPrivateKey(privateKey []byte) (ssh.AuthMethod, error) {
 	return authMethod, nil
 }
 
-func (c *SftpClient) Open(_ context.Context) error {
+func NewAuthMethodFromPassword(password string) ssh.AuthMethod {
+	return ssh.Password(password)
+}
+
+// NewAuthMethodFromKeyboardInteractive answers the questions of the server with the answers keyed by question,
+// e.g. {"Password": "secret"}. Questions are matched case-insensitively, ignoring the trailing colon and spaces.
+// A question without answer fails with ErrNoAnswer.
+func NewAuthMethodFromKeyboardInteractive(answers map[string]string) ssh.AuthMethod {
+	normalized := make(map[string]string, len(answers))
+	for question, answer := range answers {
+		normalized[normalizeQuestion(question)] = answer
+	}
+
+	return ssh.KeyboardInteractive(func(_, _ string, questions []string, _ []bool) ([]string, error) {
+		replies := make([]string, len(questions))
+
+		for i, question := range questions {
+			answer, ok := normalized[normalizeQuestion(question)]
+			if !ok {
+				return nil, fmt.Errorf("%w: %q", ErrNoAnswer, question)
+			}
+
+			replies[i] = answer
+		}
+
+		return replies, nil
+	})
+}
+
+func normalizeQuestion(question string) string {
+	return strings.ToLower(strings.TrimRight(strings.TrimSpace(question), ": "))
+}
+
+// Open connects to the server, the connection is bound to ctx, i.e. it is dropped once ctx is done,
+// so that no operation blocks on a stalled server. Connecting is bounded by the handshake timeout, so ctx
+// must last as long as the connection is used. A closed client can be opened again.
+func (c *SftpClient) Open(ctx context.Context) error {
+	return c.open(ctx, ctx)
+}
+
+// open connects to the server within ctx, the connection is bound to lifeCtx, e.g. of a pool which outlives ctx.
+func (c *SftpClient) open(ctx context.Context, lifeCtx context.Context) error {
+	if c.destServer.config == nil {
+		return &batch.IllegalArgumentError{Name: "config", Value: nil, Err: ErrNoSSHConfig}
+	}
+
+	handshakeTimeout := c.destServer.config.Timeout
+	if c.handshakeTimeout != nil {
+		handshakeTimeout = *c.handshakeTimeout
+	}
+
+	if handshakeTimeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, handshakeTimeout)
+		defer cancel()
+	}
+
 	if c.delegate != nil {
 		c.logger.Info("connection is already opened", slog.String("address", c.destServer.address))
 
@@ -83,27 +156,87 @@ func (c *SftpClient) Open(_ context.Context) error {
 	var errR error
 
 	c.openOnce.Do(func() {
-		destServerClient, err := ssh.Dial("tcp", c.destServer.address, c.destServer.config)
+		dialer := &net.Dialer{}
+
+		conn, err := dialer.DialContext(ctx, "tcp", c.destServer.address)
 		if err != nil {
-			errR = &batch.ConnectionError{Operation: batch.ConnOpen, Address: c.destServer.address, Err: err}
+			errR = &batch.ConnectionError{Operation: batch.ConnOpen, Address: c.destServer.address, Err: contextError(ctx, err)}
 
 			return
 		}
 
+		stop := context.AfterFunc(ctx, func() {
+			_ = conn.Close()
+		})
+
+		config, handshake := c.recordHandshake()
+
+		sshConn, chans, reqs, err := ssh.NewClientConn(conn, c.destServer.address, config)
+		if err != nil {
+			stop()
+			_ = conn.Close()
+
+			if handshake.hostKeyErr != nil {
+				// ssh does not wrap the error of the callback
+				errR = &batch.ConnectionError{
+					Operation: batch.ConnOpen,
+					Address:   c.destServer.address,
+					Reason:    batch.CodeHostKeyMismatch,
+					Err:       handshake.hostKeyErr,
+				}
+
+				return
+			}
+
+			errR = &batch.ConnectionError{
+				Operation: batch.ConnOpen,
+				Address:   c.destServer.address,
+				Reason:    handshake.failureReason(ctx, err),
+				Err:       contextError(ctx, err),
+			}
+
+			return
+		}
+
+		destServerClient := ssh.NewClient(sshConn, chans, reqs)
+
 		sftpClient, err := sftp.NewClient(destServerClient)
 		if err != nil {
-			errR = &batch.ConnectionError{Operation: batch.ConnOpen, Address: c.destServer.address, Err: err}
+			stop()
+			_ = destServerClient.Close()
+
+			errR = &batch.ConnectionError{Operation: batch.ConnOpen, Address: c.destServer.address, Err: contextError(ctx, err)}
+
+			return
+		}
+
+		if !stop() {
+			// ctx is done, the connection is closed already
+			_ = sftpClient.Close()
+
+			errR = &batch.ConnectionError{Operation: batch.ConnOpen, Address: c.destServer.address, Err: context.Cause(ctx)}
 
 			return
 		}
 
 		c.delegate = sftpClient
+		c.sshClient = destServerClient
+		c.stop = context.AfterFunc(lifeCtx, func() {
+			_ = conn.Close()
+		})
+		c.closeOnce = sync.Once{}
 	})
 
+	if errR != nil {
+		// a failed open can be retried
+		c.openOnce = sync.Once{}
+	}
+
 	return errR
 }
 
-func (c *SftpClient) Close(_ context.Context) error {
+// Close closes the connection gracefully, it drops the connection once ctx is done.
+func (c *SftpClient) Close(ctx context.Context) error {
 	if c.delegate == nil {
 		c.logger.Info("connection is not opened, skip close", slog.String("address", c.destServer.address))
 
@@ -113,11 +246,27 @@ func (c *SftpClient) Close(_ context.Context) error {
 	var errR error
 
 	c.closeOnce.Do(func() {
+		c.stop()
+
+		sshClient := c.sshClient
+
+		// closing the sftp client waits for the server to end the session
+		stop := context.AfterFunc(ctx, func() {
+			_ = sshClient.Close()
+		})
+		defer stop()
+
 		if err := c.delegate.Close(); err != nil {
-			errR = &batch.ConnectionError{Operation: batch.ConnClose, Address: c.destServer.address, Err: err}
+			errR = &batch.ConnectionError{Operation: batch.ConnClose, Address: c.destServer.address, Err: contextError(ctx, err)}
+		}
+
+		if err := sshClient.Close(); err != nil && !errors.Is(err, net.ErrClosed) && errR == nil {
+			errR = &batch.ConnectionError{Operation: batch.ConnClose, Address: c.destServer.address, Err: contextError(ctx, err)}
 		}
 
 		c.delegate = nil
+		c.sshClient = nil
+		c.openOnce = sync.Once{}
 	})
 
 	return errR
@@ -131,3 +280,52 @@ func (c *SftpClient) OpenFile(path string, flag int) (*sftp.File, error) {
 
 	return file, nil
 }
+
+func (c *SftpClient) ReadDir(path string) ([]os.FileInfo, error) {
+	files, err := c.delegate.ReadDir(path)
+	if err != nil {
+		return nil, &batch.IoError{Operation: batch.IoRead, Resource: path, Err: err}
+	}
+
+	return files, nil
+}
+
+// handshakeState records how far the ssh handshake went, as ssh does not return typed errors.
+type handshakeState struct {
+	hostKeyErr error
+	// the host key is accepted, i.e. the key exchange is done and the client authenticates
+	authenticating bool
+}
+
+// recordHandshake returns a copy of the config, which records the state of the handshake.
+func (c *SftpClient) recordHandshake() (*ssh.ClientConfig, *handshakeState) {
+	config := *c.destServer.config
+	handshake := &handshakeState{}
+
+	if callback := config.HostKeyCallback; callback != nil {
+		config.HostKeyCallback = func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			handshake.hostKeyErr = callback(hostname, remote, key)
+			handshake.authenticating = handshake.hostKeyErr == nil
+
+			return handshake.hostKeyErr
+		}
+	}
+
+	return &config, handshake
+}
+
+// failureReason tells whether the server rejected the credentials, or no answer is configured for a question.
+// Other failures after the key exchange, e.g. a dropped connection, are left unclassified, so they are retried.
+func (h *handshakeState) failureReason(ctx context.Context, err error) batch.ErrorCode {
+	if !h.authenticating || ctx.Err() != nil {
+		return ""
+	}
+
+	// ssh does not return typed errors, nor wraps the error of an auth method
+	message := err.Error()
+	if strings.Contains(message, "unable to authenticate") || strings.Contains(message, ErrNoAnswer.Error()) {
+		return batch.CodeAuthentication
+	}
+
+	return ""
+}
@@ -0,0 +1,497 @@
+package resource
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/ivxivx/go-recon/batch"
+)
+
+const (
+	defaultSftpKeepAliveInterval = 30 * time.Second
+	defaultSftpIdleTimeout       = 5 * time.Minute
+)
+
+var ErrPoolClosed = errors.New("pool closed")
+
+// SftpClientPool shares a connection per server address and ssh config among resources, so that reading several
+// files from one server needs one ssh handshake. The config is compared by pointer, so the resources of a server
+// must be given the same *ssh.ClientConfig to share the connection, or a key by ClientWithKey. Servers with
+// a different config, e.g. another host key check, do not share the connection. A resource borrows the connection
+// when it is opened, and returns it when it is closed. Idle connections are kept alive, and evicted after the idle
+// timeout. A broken connection is replaced by a new one when it is borrowed next. As the connection outlives the ctx
+// of a borrower, the connection is dropped if the ctx is done while the borrower waits for the server, which cannot
+// be told apart from a stall.
+type SftpClientPool struct {
+	logger            *slog.Logger
+	keepAliveInterval time.Duration
+	idleTimeout       time.Duration
+
+	// bounds the pooled connections, which outlive the context of a borrower
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu      sync.Mutex
+	servers map[sftpPoolKey]*sftpPoolEntry
+	closed  bool
+	started bool
+	done    chan struct{}
+}
+
+// sftpPoolKey compares the config by identity, the callbacks it holds cannot be compared otherwise.
+// A key given by the caller replaces the config.
+type sftpPoolKey struct {
+	address string
+	config  *ssh.ClientConfig
+	key     string
+}
+
+// sftpPoolEntry is the connection to a server, it is replaced if broken.
+type sftpPoolEntry struct {
+	server *SSHServer
+
+	mu   sync.Mutex // held while connecting, so that borrowers share one handshake
+	conn *sftpPooledConn
+}
+
+type sftpPooledConn struct {
+	client    *SftpClient
+	sshClient *ssh.Client // kept, as the client clears it on close
+	refs      int
+	lastUsed  time.Time
+	broken    bool
+}
+
+func NewSftpClientPool(logger *slog.Logger) *SftpClientPool {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &SftpClientPool{
+		logger:            logger,
+		keepAliveInterval: defaultSftpKeepAliveInterval,
+		idleTimeout:       defaultSftpIdleTimeout,
+		ctx:               ctx,
+		cancel:            cancel,
+		servers:           make(map[sftpPoolKey]*sftpPoolEntry),
+		done:              make(chan struct{}),
+	}
+}
+
+// WithKeepAlive sets how often idle connections are checked, a connection which does not reply is evicted.
+func (p *SftpClientPool) WithKeepAlive(interval time.Duration) *SftpClientPool {
+	p.keepAliveInterval = interval
+
+	return p
+}
+
+// WithIdleTimeout sets how long a connection is kept once no resource borrows it, it is checked with the keepalive.
+func (p *SftpClientPool) WithIdleTimeout(timeout time.Duration) *SftpClientPool {
+	p.idleTimeout = timeout
+
+	return p
+}
+
+// Client returns a client which borrows the pooled connection to the server on Open, and returns it on Close.
+// Each resource needs its own client. The connection is shared with the servers of the same address and
+// the same *ssh.ClientConfig.
+func (p *SftpClientPool) Client(server *SSHServer) ISftpClient {
+	return p.client(sftpPoolKey{address: server.address, config: server.config}, server)
+}
+
+// ClientWithKey is like Client, but the connection is shared with the servers of the same address and key,
+// e.g. the name of the credentials, whatever their config is. The caller vouches that configs of the same key
+// are equivalent, the config of the server which connects first is used.
+func (p *SftpClientPool) ClientWithKey(server *SSHServer, key string) ISftpClient {
+	return p.client(sftpPoolKey{address: server.address, key: key}, server)
+}
+
+func (p *SftpClientPool) client(key sftpPoolKey, server *SSHServer) ISftpClient {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	entry, found := p.servers[key]
+	if !found {
+		entry = &sftpPoolEntry{server: server}
+
+		// a closed pool fails to lend the connection
+		if !p.closed {
+			p.servers[key] = entry
+		}
+	}
+
+	return &pooledSftpClient{pool: p, entry: entry}
+}
+
+// acquire borrows the connection of the entry, it connects within ctx if there is no usable connection.
+func (p *SftpClientPool) acquire(ctx context.Context, entry *sftpPoolEntry) (*sftpPooledConn, error) {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+
+		return nil, &batch.ConnectionError{Operation: batch.ConnOpen, Address: entry.server.address, Err: ErrPoolClosed}
+	}
+
+	if !p.started {
+		p.started = true
+
+		go p.maintain()
+	}
+	p.mu.Unlock()
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	if conn := entry.conn; conn != nil && !conn.broken {
+		conn.refs++
+
+		return conn, nil
+	}
+
+	// a broken connection is closed once it is returned by all borrowers
+	if conn := entry.conn; conn != nil && conn.refs == 0 {
+		p.closeConn(ctx, conn)
+	}
+
+	client := NewSftpClient(p.logger, entry.server)
+
+	if err := client.open(ctx, p.ctx); err != nil {
+		return nil, err
+	}
+
+	p.logger.Info("pooled sftp connection opened", slog.String("address", entry.server.address))
+
+	entry.conn = &sftpPooledConn{client: client, sshClient: client.sshClient, refs: 1}
+
+	return entry.conn, nil
+}
+
+// release returns the connection, a broken or replaced connection is closed once it is returned by all borrowers.
+func (p *SftpClientPool) release(ctx context.Context, entry *sftpPoolEntry, conn *sftpPooledConn) {
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	conn.refs--
+	conn.lastUsed = time.Now()
+
+	if conn.refs == 0 && (conn.broken || conn != entry.conn) {
+		p.closeConn(ctx, conn)
+
+		if conn == entry.conn {
+			entry.conn = nil
+		}
+	}
+}
+
+// markBroken makes the next borrower connect again, e.g. after the connection is lost.
+func (p *SftpClientPool) markBroken(entry *sftpPoolEntry, conn *sftpPooledConn, err error) {
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	if !conn.broken {
+		p.logger.Warn("pooled sftp connection is broken", slog.String("address", entry.server.address), slog.Any("error", err))
+	}
+
+	conn.broken = true
+}
+
+// drop closes the connection without waiting for the server, which unblocks the operations of all borrowers.
+func (p *SftpClientPool) drop(entry *sftpPoolEntry, conn *sftpPooledConn, err error) {
+	_ = conn.sshClient.Close()
+
+	p.markBroken(entry, conn, err)
+}
+
+func (p *SftpClientPool) closeConn(ctx context.Context, conn *sftpPooledConn) {
+	if err := conn.client.Close(ctx); err != nil {
+		p.logger.Info("failed to close pooled sftp connection", slog.String("address", conn.client.destServer.address), slog.Any("error", err))
+	}
+}
+
+// maintain keeps idle connections alive, and evicts them after the idle timeout, until the pool is closed.
+func (p *SftpClientPool) maintain() {
+	defer close(p.done)
+
+	ticker := time.NewTicker(p.keepAliveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		p.mu.Lock()
+		entries := make([]*sftpPoolEntry, 0, len(p.servers))
+		for _, entry := range p.servers {
+			entries = append(entries, entry)
+		}
+		p.mu.Unlock()
+
+		for _, entry := range entries {
+			p.maintainEntry(entry)
+		}
+	}
+}
+
+// maintainEntry checks the idle connection of the entry, the connection can be borrowed during the keepalive.
+func (p *SftpClientPool) maintainEntry(entry *sftpPoolEntry) {
+	ctx, cancel := context.WithTimeout(p.ctx, p.keepAliveInterval)
+	defer cancel()
+
+	entry.mu.Lock()
+
+	conn := entry.conn
+	if conn == nil || conn.refs > 0 || conn.broken {
+		entry.mu.Unlock()
+
+		return
+	}
+
+	if time.Since(conn.lastUsed) >= p.idleTimeout {
+		entry.conn = nil
+		entry.mu.Unlock()
+
+		p.logger.Info("idle sftp connection evicted", slog.String("address", entry.server.address))
+		p.closeConn(ctx, conn)
+
+		return
+	}
+
+	entry.mu.Unlock()
+
+	err := p.keepAlive(ctx, conn)
+	if err == nil {
+		return
+	}
+
+	p.logger.Warn("sftp keepalive failed, connection evicted", slog.String("address", entry.server.address), slog.Any("error", err))
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	// a connection borrowed during the keepalive is closed once it is returned
+	conn.broken = true
+
+	if conn.refs == 0 && conn == entry.conn {
+		entry.conn = nil
+
+		p.closeConn(ctx, conn)
+	}
+}
+
+// keepAlive sends a request which the server has to reply, a server which does not reply within ctx is considered gone.
+func (p *SftpClientPool) keepAlive(ctx context.Context, conn *sftpPooledConn) error {
+	address := conn.client.destServer.address
+	done := make(chan error, 1)
+
+	go func() {
+		_, _, err := conn.sshClient.SendRequest("keepalive@openssh.com", true, nil)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return &batch.ConnectionError{Operation: batch.ConnOpen, Address: address, Err: err}
+		}
+
+		return nil
+	case <-ctx.Done():
+		// closing the connection unblocks the request
+		_ = conn.sshClient.Close()
+
+		return &batch.ConnectionError{Operation: batch.ConnOpen, Address: address, Err: ctx.Err()}
+	}
+}
+
+// Close closes all connections, including borrowed ones, the pool cannot be used afterwards.
+func (p *SftpClientPool) Close(ctx context.Context) error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+
+		return nil
+	}
+
+	p.closed = true
+	started := p.started
+	entries := p.servers
+	p.servers = nil
+	p.mu.Unlock()
+
+	for _, entry := range entries {
+		entry.mu.Lock()
+		if entry.conn != nil {
+			p.closeConn(ctx, entry.conn)
+			entry.conn = nil
+		}
+		entry.mu.Unlock()
+	}
+
+	// drops the connections which are still being opened
+	p.cancel()
+
+	if started {
+		<-p.done
+	}
+
+	return nil
+}
+
+// sftpOperationGuard is implemented by clients whose connection outlives the ctx of Open, an operation
+// on the connection is begun by begin, and ended by the returned func.
+type sftpOperationGuard interface {
+	begin() (func(), error)
+}
+
+// beginSftpOperation lets a guarding client drop a stalled connection once the ctx of Open is done.
+func beginSftpOperation(client ISftpClient) (func(), error) {
+	if guard, cok := client.(sftpOperationGuard); cok {
+		return guard.begin()
+	}
+
+	// the connection is bound to the ctx of Open
+	return func() {}, nil
+}
+
+// pooledSftpClient borrows the pooled connection to a server.
+type pooledSftpClient struct {
+	pool  *SftpClientPool
+	entry *sftpPoolEntry
+
+	mu       sync.Mutex
+	conn     *sftpPooledConn
+	ctx      context.Context
+	stop     func() bool
+	inFlight int
+}
+
+var (
+	_ ISftpClient        = (*pooledSftpClient)(nil)
+	_ sftpOperationGuard = (*pooledSftpClient)(nil)
+)
+
+// Open borrows the connection, operations which are in flight once ctx is done drop the connection.
+func (c *pooledSftpClient) Open(ctx context.Context) error {
+	if c.conn != nil {
+		c.pool.logger.Info("connection is already borrowed", slog.String("address", c.entry.server.address))
+
+		return nil
+	}
+
+	conn, err := c.pool.acquire(ctx, c.entry)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.conn = conn
+	c.ctx = ctx
+	c.inFlight = 0
+	c.mu.Unlock()
+
+	c.stop = context.AfterFunc(ctx, c.interrupt)
+
+	return nil
+}
+
+// Close returns the connection to the pool, a canceled ctx does not break the connection.
+func (c *pooledSftpClient) Close(ctx context.Context) error {
+	if c.conn == nil {
+		return nil
+	}
+
+	c.stop()
+
+	c.mu.Lock()
+	conn := c.conn
+	c.conn = nil
+	c.mu.Unlock()
+
+	c.pool.release(ctx, c.entry, conn)
+
+	return nil
+}
+
+func (c *pooledSftpClient) begin() (func(), error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conn == nil {
+		return nil, ErrResourceNotOpened
+	}
+
+	// checked under the lock, so that interrupt sees every operation begun before ctx is done
+	if err := c.ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	c.inFlight++
+
+	return func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		c.inFlight--
+	}, nil
+}
+
+// interrupt drops the connection if an operation is in flight, as it may wait for a stalled server forever.
+func (c *pooledSftpClient) interrupt() {
+	c.mu.Lock()
+	conn := c.conn
+	inFlight := c.inFlight
+	c.mu.Unlock()
+
+	if conn == nil || inFlight == 0 {
+		return
+	}
+
+	c.pool.drop(c.entry, conn, context.Cause(c.ctx))
+}
+
+func (c *pooledSftpClient) OpenFile(path string, flag int) (*sftp.File, error) {
+	end, err := c.begin()
+	if err != nil {
+		return nil, &batch.IoError{Operation: batch.IoOpen, Resource: path, Err: err}
+	}
+	defer end()
+
+	file, err := c.conn.client.OpenFile(path, flag)
+	c.checkConnection(err)
+
+	return file, err
+}
+
+func (c *pooledSftpClient) ReadDir(path string) ([]os.FileInfo, error) {
+	end, err := c.begin()
+	if err != nil {
+		return nil, &batch.IoError{Operation: batch.IoRead, Resource: path, Err: err}
+	}
+	defer end()
+
+	files, err := c.conn.client.ReadDir(path)
+	c.checkConnection(err)
+
+	return files, err
+}
+
+// checkConnection marks the connection broken if err tells that it is lost.
+func (c *pooledSftpClient) checkConnection(err error) {
+	if err == nil {
+		return
+	}
+
+	if errors.Is(err, sftp.ErrSSHFxConnectionLost) || errors.Is(err, io.EOF) || errors.Is(err, net.ErrClosed) {
+		c.pool.markBroken(c.entry, c.conn, err)
+	}
+}
@@ -0,0 +1,429 @@
+package resource
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/ivxivx/go-recon/batch"
+)
+
+// newTestCountingSftpServer starts an in-memory sftp server, which counts the ssh handshakes.
+func newTestCountingSftpServer(t *testing.T) (*SSHServer, *atomic.Int32) {
+	t.Helper()
+
+	var handshakes atomic.Int32
+
+	config := &ssh.ServerConfig{
+		NoClientAuth: true,
+		NoClientAuthCallback: func(_ ssh.ConnMetadata) (*ssh.Permissions, error) {
+			handshakes.Add(1)
+
+			return nil, nil
+		},
+	}
+	config.AddHostKey(newTestHostKey(t))
+
+	address := newTestSftpServerWithConfig(t, config, sftp.InMemHandler())
+
+	return NewSSHServer(address, &ssh.ClientConfig{
+		User:            "test",
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+	}), &handshakes
+}
+
+func writeTestSftpFile(ctx context.Context, t *testing.T, pool *SftpClientPool, server *SSHServer, filePath string, data string) {
+	t.Helper()
+
+	resource := NewSftpResource(slog.Default(), pool.Client(server), filePath).WithFlag(os.O_WRONLY | os.O_CREATE | os.O_TRUNC)
+
+	if err := resource.Open(ctx); err != nil {
+		t.Fatalf("failed to open %s for writing: %v", filePath, err)
+	}
+
+	if _, err := resource.Write([]byte(data)); err != nil {
+		t.Fatalf("failed to write %s: %v", filePath, err)
+	}
+
+	if err := resource.Close(ctx); err != nil {
+		t.Fatalf("failed to close %s: %v", filePath, err)
+	}
+}
+
+func readTestSftpFile(ctx context.Context, t *testing.T, resource *SftpResource) string {
+	t.Helper()
+
+	if err := resource.Open(ctx); err != nil {
+		t.Fatalf("failed to open resource: %v", err)
+	}
+
+	data, err := io.ReadAll(resource)
+	if err != nil {
+		t.Fatalf("failed to read resource: %v", err)
+	}
+
+	if err := resource.Close(ctx); err != nil {
+		t.Fatalf("failed to close resource: %v", err)
+	}
+
+	return string(data)
+}
+
+func Test_SftpClientPool_SharesConnection(t *testing.T) {
+	t.Parallel()
+
+	server, handshakes := newTestCountingSftpServer(t)
+
+	pool := NewSftpClientPool(slog.Default())
+	ctx := context.Background()
+
+	defer pool.Close(ctx)
+
+	files := []string{"/report_1.csv", "/report_2.csv", "/report_3.csv"}
+
+	for _, file := range files {
+		writeTestSftpFile(ctx, t, pool, server, file, file)
+	}
+
+	// opened at the same time
+	resources := make([]*SftpResource, 0, len(files))
+
+	for _, file := range files {
+		resource := NewSftpResource(slog.Default(), pool.Client(server), file)
+
+		if err := resource.Open(ctx); err != nil {
+			t.Fatalf("failed to open resource: %v", err)
+		}
+
+		resources = append(resources, resource)
+	}
+
+	for i, resource := range resources {
+		data, err := io.ReadAll(resource)
+		if err != nil || string(data) != files[i] {
+			t.Fatalf("unexpected data %q, error: %v", data, err)
+		}
+
+		if err := resource.Close(ctx); err != nil {
+			t.Fatalf("failed to close resource: %v", err)
+		}
+	}
+
+	// a returned client can be borrowed again
+	if data := readTestSftpFile(ctx, t, resources[0]); data != files[0] {
+		t.Fatalf("unexpected data %q after reopening", data)
+	}
+
+	if count := handshakes.Load(); count != 1 {
+		t.Fatalf("expected 1 handshake, got %d", count)
+	}
+}
+
+func Test_SftpClientPool_Evict(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name string
+		pool func(pool *SftpClientPool) *SftpClientPool
+		// breakConn breaks the connection once it is returned
+		breakConn func(conn *sftpPooledConn)
+	}{
+		{
+			name: "idle timeout",
+			pool: func(pool *SftpClientPool) *SftpClientPool {
+				return pool.WithKeepAlive(10 * time.Millisecond).WithIdleTimeout(30 * time.Millisecond)
+			},
+			breakConn: func(_ *sftpPooledConn) {},
+		},
+		{
+			name: "keepalive failed",
+			pool: func(pool *SftpClientPool) *SftpClientPool {
+				return pool.WithKeepAlive(10 * time.Millisecond)
+			},
+			breakConn: func(conn *sftpPooledConn) {
+				_ = conn.sshClient.Close()
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			server, handshakes := newTestCountingSftpServer(t)
+
+			pool := tc.pool(NewSftpClientPool(slog.Default()))
+			ctx := context.Background()
+
+			t.Cleanup(func() { pool.Close(ctx) })
+
+			writeTestSftpFile(ctx, t, pool, server, "/report.csv", "id,amount\n")
+
+			entry := pool.Client(server).(*pooledSftpClient).entry
+
+			entry.mu.Lock()
+			tc.breakConn(entry.conn)
+			entry.mu.Unlock()
+
+			evicted := func() bool {
+				entry.mu.Lock()
+				defer entry.mu.Unlock()
+
+				return entry.conn == nil
+			}
+
+			deadline := time.Now().Add(testTimeout)
+			for !evicted() {
+				if time.Now().After(deadline) {
+					t.Fatalf("connection is not evicted")
+				}
+
+				time.Sleep(10 * time.Millisecond)
+			}
+
+			if data := readTestSftpFile(ctx, t, NewSftpResource(slog.Default(), pool.Client(server), "/report.csv")); data != "id,amount\n" {
+				t.Fatalf("unexpected data %q after reconnecting", data)
+			}
+
+			if count := handshakes.Load(); count != 2 {
+				t.Fatalf("expected 2 handshakes, got %d", count)
+			}
+		})
+	}
+}
+
+func Test_SftpClientPool_ReplaceBroken(t *testing.T) {
+	t.Parallel()
+
+	server, handshakes := newTestCountingSftpServer(t)
+
+	pool := NewSftpClientPool(slog.Default())
+	ctx := context.Background()
+
+	defer pool.Close(ctx)
+
+	writeTestSftpFile(ctx, t, pool, server, "/report.csv", "id,amount\n")
+
+	client := pool.Client(server).(*pooledSftpClient)
+
+	if err := client.Open(ctx); err != nil {
+		t.Fatalf("failed to open client: %v", err)
+	}
+
+	lost := client.conn
+
+	_ = lost.sshClient.Close()
+
+	if _, err := client.ReadDir("/"); err == nil {
+		t.Fatalf("expected the lost connection to fail")
+	}
+
+	// borrowed while the broken connection is not returned yet
+	if data := readTestSftpFile(ctx, t, NewSftpResource(slog.Default(), pool.Client(server), "/report.csv")); data != "id,amount\n" {
+		t.Fatalf("unexpected data %q after reconnecting", data)
+	}
+
+	if lost.client.sshClient == nil {
+		t.Fatalf("expected the borrowed connection to stay open")
+	}
+
+	_ = client.Close(ctx)
+
+	if lost.client.sshClient != nil {
+		t.Fatalf("expected the broken connection to be closed once it is returned")
+	}
+
+	if count := handshakes.Load(); count != 2 {
+		t.Fatalf("expected 2 handshakes, got %d", count)
+	}
+}
+
+func Test_SftpClientPool_StalledServer(t *testing.T) {
+	t.Parallel()
+
+	handler := &stalledHandler{reader: &stalledReaderAt{release: make(chan struct{})}}
+	defer close(handler.reader.release)
+
+	address := newTestSftpServer(t, sftp.Handlers{
+		FileGet:  handler,
+		FilePut:  sftp.InMemHandler().FilePut,
+		FileCmd:  sftp.InMemHandler().FileCmd,
+		FileList: handler,
+	})
+
+	server := NewSSHServer(address, &ssh.ClientConfig{
+		User:            "test",
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+	})
+
+	pool := NewSftpClientPool(slog.Default())
+
+	// drops the connection if it is still stalled
+	closeCtx, closeCancel := context.WithTimeout(context.Background(), time.Second)
+	defer closeCancel()
+
+	defer pool.Close(closeCtx)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client := pool.Client(server)
+	resource := NewSftpResource(slog.Default(), client, "/report.csv")
+
+	if err := resource.Open(ctx); err != nil {
+		t.Fatalf("failed to open resource: %v", err)
+	}
+
+	conn := client.(*pooledSftpClient).conn
+
+	done := make(chan error, 1)
+
+	go func() {
+		_, err := resource.Read(make([]byte, 10))
+		done <- err
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected canceled, got %v", err)
+		}
+	case <-time.After(testTimeout):
+		t.Fatalf("read did not return after cancellation")
+	}
+
+	_ = resource.Close(closeCtx)
+
+	if conn.client.sshClient != nil {
+		t.Fatalf("expected the stalled connection to be closed")
+	}
+}
+
+func Test_SftpClientPool_CanceledAfterRead(t *testing.T) {
+	t.Parallel()
+
+	server, handshakes := newTestCountingSftpServer(t)
+
+	pool := NewSftpClientPool(slog.Default())
+
+	defer pool.Close(context.Background())
+
+	writeTestSftpFile(context.Background(), t, pool, server, "/report.csv", "id,amount\n")
+
+	for range 2 {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		resource := NewSftpResource(slog.Default(), pool.Client(server), "/report.csv")
+
+		if err := resource.Open(ctx); err != nil {
+			t.Fatalf("failed to open resource: %v", err)
+		}
+
+		if data, err := io.ReadAll(resource); err != nil || string(data) != "id,amount\n" {
+			t.Fatalf("unexpected data %q, error: %v", data, err)
+		}
+
+		// the run is done before the resource is closed
+		cancel()
+
+		_ = resource.Close(ctx)
+	}
+
+	if count := handshakes.Load(); count != 1 {
+		t.Fatalf("expected 1 handshake, got %d", count)
+	}
+}
+
+func Test_SftpClientPool_DifferentConfig(t *testing.T) {
+	t.Parallel()
+
+	server, handshakes := newTestCountingSftpServer(t)
+
+	pool := NewSftpClientPool(slog.Default())
+	ctx := context.Background()
+
+	defer pool.Close(ctx)
+
+	client := pool.Client(server)
+
+	if err := client.Open(ctx); err != nil {
+		t.Fatalf("failed to open client: %v", err)
+	}
+
+	defer client.Close(ctx)
+
+	// the same server and user, but another host key is expected
+	strict := NewSSHServer(server.address, &ssh.ClientConfig{
+		User:            "test",
+		HostKeyCallback: ssh.FixedHostKey(newTestHostKey(t).PublicKey()),
+	})
+
+	err := pool.Client(strict).Open(ctx)
+	if batch.CodeOf(err) != batch.CodeHostKeyMismatch {
+		t.Fatalf("expected host key mismatch, got %s: %v", batch.CodeOf(err), err)
+	}
+
+	if count := handshakes.Load(); count != 1 {
+		t.Fatalf("expected 1 handshake, got %d", count)
+	}
+}
+
+func Test_SftpClientPool_Closed(t *testing.T) {
+	t.Parallel()
+
+	server, _ := newTestCountingSftpServer(t)
+
+	pool := NewSftpClientPool(slog.Default())
+	ctx := context.Background()
+
+	if err := pool.Close(ctx); err != nil {
+		t.Fatalf("failed to close pool: %v", err)
+	}
+
+	if err := pool.Client(server).Open(ctx); !errors.Is(err, ErrPoolClosed) {
+		t.Fatalf("expected pool closed, got %v", err)
+	}
+}
+
+func Test_SftpClientPool_ClientWithKey(t *testing.T) {
+	t.Parallel()
+
+	server, handshakes := newTestCountingSftpServer(t)
+
+	pool := NewSftpClientPool(slog.Default())
+	ctx := context.Background()
+
+	defer pool.Close(ctx)
+
+	// each resource builds its own, equivalent config
+	newServer := func() *SSHServer {
+		return NewSSHServer(server.address, &ssh.ClientConfig{
+			User:            "test",
+			HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		})
+	}
+
+	writeTestSftpFile(ctx, t, pool, newServer(), "/report.csv", "id,amount\n")
+
+	for _, client := range []ISftpClient{pool.ClientWithKey(newServer(), "test"), pool.ClientWithKey(newServer(), "test")} {
+		if data := readTestSftpFile(ctx, t, NewSftpResource(slog.Default(), client, "/report.csv")); data != "id,amount\n" {
+			t.Fatalf("unexpected data %q", data)
+		}
+	}
+
+	// one handshake of the writer, one shared by the key
+	if count := handshakes.Load(); count != 2 {
+		t.Fatalf("expected 2 handshakes, got %d", count)
+	}
+}
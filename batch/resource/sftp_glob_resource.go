@@ -0,0 +1,338 @@
+package resource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"sync"
+
+	"github.com/pkg/sftp"
+
+	"github.com/ivxivx/go-recon/batch"
+)
+
+// SftpGlobResource reads the files in a directory on an sftp server whose names match a pattern,
+// e.g. Report_20240801_*.csv for files with unpredictable suffixes. The files are read one by one in
+// the order of their names, each file is a page, so that a reader can read them as one input.
+type SftpGlobResource struct {
+	logger  *slog.Logger
+	client  ISftpClient
+	dir     string
+	pattern string
+	match   func(name string) (bool, error)
+	newest  bool
+	err     error // of an invalid pattern, Open fails with it
+
+	openOnce  sync.Once
+	closeOnce sync.Once
+
+	files []os.FileInfo // matching files, in the order of reading
+	index int
+	file  *sftp.File
+	size  int64
+	ctx   context.Context // bounds reads, which have no context
+}
+
+// NewSftpGlobResource matches the names of the files with a shell pattern, see path.Match.
+// Open fails with batch.IllegalArgumentError if the pattern is malformed.
+func NewSftpGlobResource(logger *slog.Logger, client ISftpClient, dir string, pattern string) *SftpGlobResource {
+	resource := &SftpGlobResource{
+		logger:  logger,
+		client:  client,
+		dir:     dir,
+		pattern: pattern,
+		match: func(name string) (bool, error) {
+			return path.Match(pattern, name)
+		},
+	}
+
+	// the whole pattern is checked, even if the name does not match
+	if _, err := path.Match(pattern, ""); err != nil {
+		resource.err = &batch.IllegalArgumentError{Name: "pattern", Value: pattern, Err: err}
+	}
+
+	return resource
+}
+
+// NewSftpRegexpResource matches the names of the files with a regular expression.
+func NewSftpRegexpResource(logger *slog.Logger, client ISftpClient, dir string, pattern *regexp.Regexp) *SftpGlobResource {
+	return &SftpGlobResource{
+		logger:  logger,
+		client:  client,
+		dir:     dir,
+		pattern: pattern.String(),
+		match: func(name string) (bool, error) {
+			return pattern.MatchString(name), nil
+		},
+	}
+}
+
+// WithNewestOnly reads only the matching file which was modified last, e.g. the latest version of a report.
+func (r *SftpGlobResource) WithNewestOnly() *SftpGlobResource {
+	r.newest = true
+
+	return r
+}
+
+var (
+	_ batch.PagedResource = (*SftpGlobResource)(nil)
+	_ batch.Sizer         = (*SftpGlobResource)(nil)
+)
+
+func (r *SftpGlobResource) GetID() string {
+	return path.Join(r.dir, r.pattern)
+}
+
+// GetFilePaths returns the paths of the matching files once opened, in the order of reading.
+func (r *SftpGlobResource) GetFilePaths() []string {
+	paths := make([]string, 0, len(r.files))
+
+	for _, file := range r.files {
+		paths = append(paths, path.Join(r.dir, file.Name()))
+	}
+
+	return paths
+}
+
+// Size is the total size of the matching files.
+func (r *SftpGlobResource) Size() int64 {
+	if r.file == nil {
+		return -1
+	}
+
+	return r.size
+}
+
+// Open lists the directory and opens the first matching file, the connection is dropped once ctx is done.
+// It fails with fs.ErrNotExist if no file matches.
+func (r *SftpGlobResource) Open(ctx context.Context) error {
+	if r.err != nil {
+		return r.err
+	}
+
+	if r.files != nil {
+		r.logger.Warn("resource is already opened", slog.String("resource", r.GetID()))
+
+		return nil
+	}
+
+	var errR error
+
+	r.openOnce.Do(func() {
+		err := r.client.Open(ctx)
+		if err != nil {
+			errR = fmt.Errorf("could not open sftp client for %s: %w", r.GetID(), err)
+
+			return
+		}
+
+		files, err := r.list()
+		if err == nil {
+			err = r.openFile(0, files)
+		}
+
+		if err != nil {
+			if errC := r.client.Close(ctx); errC != nil {
+				r.logger.Warn("failed to close sftp client", slog.String("resource", r.GetID()), slog.Any("error", errC))
+			}
+
+			errR = contextError(ctx, err)
+
+			return
+		}
+
+		r.ctx = ctx
+		r.closeOnce = sync.Once{}
+
+		r.logger.Info("files matched", slog.String("resource", r.GetID()), slog.Any("files", r.GetFilePaths()))
+	})
+
+	if errR != nil {
+		// a failed open can be retried
+		r.openOnce = sync.Once{}
+	}
+
+	return errR
+}
+
+// list returns the matching files, sorted by name, or only the newest one.
+func (r *SftpGlobResource) list() ([]os.FileInfo, error) {
+	entries, err := r.client.ReadDir(r.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []os.FileInfo
+
+	for _, entry := range entries {
+		if !entry.Mode().IsRegular() {
+			continue
+		}
+
+		matched, err := r.match(entry.Name())
+		if err != nil {
+			return nil, &batch.IllegalArgumentError{Name: "pattern", Value: r.pattern, Err: err}
+		}
+
+		if matched {
+			files = append(files, entry)
+		}
+	}
+
+	if len(files) == 0 {
+		return nil, &batch.IoError{
+			Operation: batch.IoOpen,
+			Resource:  r.GetID(),
+			Err:       fmt.Errorf("no file matches: %w", fs.ErrNotExist),
+		}
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Name() < files[j].Name()
+	})
+
+	if r.newest {
+		newest := files[0]
+
+		for _, file := range files[1:] {
+			// the later name wins a tie, e.g. _v2 over _v1
+			if !file.ModTime().Before(newest.ModTime()) {
+				newest = file
+			}
+		}
+
+		files = []os.FileInfo{newest}
+	}
+
+	return files, nil
+}
+
+func (r *SftpGlobResource) openFile(index int, files []os.FileInfo) error {
+	filePath := path.Join(r.dir, files[index].Name())
+
+	file, err := r.client.OpenFile(filePath, os.O_RDONLY)
+	if err != nil {
+		return fmt.Errorf("could not open file %s via sftp: %w", filePath, err)
+	}
+
+	if index == 0 {
+		r.size = 0
+
+		for _, info := range files {
+			r.size += info.Size()
+		}
+	}
+
+	r.files = files
+	r.index = index
+	r.file = file
+
+	return nil
+}
+
+// NextPage closes the current file and opens the next matching one, it returns io.EOF after the last file.
+func (r *SftpGlobResource) NextPage(ctx context.Context) error {
+	if r.file == nil {
+		return &batch.IoError{Operation: batch.IoRead, Resource: r.GetID(), Err: ErrResourceNotOpened}
+	}
+
+	if r.index+1 >= len(r.files) {
+		return io.EOF
+	}
+
+	err := r.closeFile(ctx)
+
+	// the file is not closed again by Close, reads fail until the resource is opened again
+	r.file = nil
+
+	if err != nil {
+		return err
+	}
+
+	if err := r.openFile(r.index+1, r.files); err != nil {
+		return contextError(r.ctx, err)
+	}
+
+	return nil
+}
+
+// closeFile closes the current file, it waits for the server until ctx is done.
+func (r *SftpGlobResource) closeFile(ctx context.Context) error {
+	filePath := path.Join(r.dir, r.files[r.index].Name())
+
+	done := make(chan error, 1)
+
+	go func(file *sftp.File) {
+		done <- file.Close()
+	}(r.file)
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return &batch.IoError{Operation: batch.IoClose, Resource: filePath, Err: err}
+		}
+
+		return nil
+	case <-ctx.Done():
+		return &batch.IoError{Operation: batch.IoClose, Resource: filePath, Err: ctx.Err()}
+	}
+}
+
+// Close closes the current file and the client, the connection is dropped once ctx is done.
+func (r *SftpGlobResource) Close(ctx context.Context) error {
+	if r.files == nil {
+		r.logger.Info("resource is not opened, skip close", slog.String("resource", r.GetID()))
+
+		return nil
+	}
+
+	var errR error
+
+	r.closeOnce.Do(func() {
+		if r.file != nil {
+			errR = r.closeFile(ctx)
+		}
+
+		if errC := r.client.Close(ctx); errC != nil {
+			r.logger.Warn("failed to close sftp client", slog.String("resource", r.GetID()), slog.Any("error", errC))
+		}
+
+		r.file = nil
+		r.files = nil
+		r.openOnce = sync.Once{}
+	})
+
+	return errR
+}
+
+// Read reads the current file, it returns io.EOF at the end of the file.
+func (r *SftpGlobResource) Read(p []byte) (n int, err error) {
+	if r.file == nil {
+		return 0, &batch.IoError{Operation: batch.IoRead, Resource: r.GetID(), Err: ErrResourceNotOpened}
+	}
+
+	if errC := r.ctx.Err(); errC != nil {
+		return 0, &batch.IoError{Operation: batch.IoRead, Resource: r.GetID(), Err: errC}
+	}
+
+	end, errB := beginSftpOperation(r.client)
+	if errB != nil {
+		return 0, &batch.IoError{Operation: batch.IoRead, Resource: r.GetID(), Err: errB}
+	}
+	defer end()
+
+	n, err = r.file.Read(p)
+
+	if err == nil || errors.Is(err, io.EOF) {
+		return n, err
+	}
+
+	return 0, &batch.IoError{Operation: batch.IoRead, Resource: r.GetID(), Err: contextError(r.ctx, err)}
+}
@@ -0,0 +1,250 @@
+package resource
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/sftp"
+
+	"github.com/ivxivx/go-recon/batch"
+)
+
+// testDirHandler serves the files of one directory, the unreadable file is listed but cannot be opened.
+type testDirHandler struct {
+	dir        string
+	files      []*testFileInfo
+	unreadable string
+}
+
+func (h *testDirHandler) find(filePath string) (*testFileInfo, error) {
+	for _, file := range h.files {
+		if path.Join(h.dir, file.name) == filePath {
+			return file, nil
+		}
+	}
+
+	return nil, os.ErrNotExist
+}
+
+func (h *testDirHandler) Fileread(r *sftp.Request) (io.ReaderAt, error) {
+	file, err := h.find(r.Filepath)
+	if err != nil {
+		return nil, err
+	}
+
+	if file.name == h.unreadable {
+		return nil, os.ErrPermission
+	}
+
+	return strings.NewReader(file.data), nil
+}
+
+func (h *testDirHandler) Filelist(r *sftp.Request) (sftp.ListerAt, error) {
+	if r.Method == "List" {
+		if r.Filepath != h.dir {
+			return nil, os.ErrNotExist
+		}
+
+		var files testFileLister
+		for _, file := range h.files {
+			files = append(files, file)
+		}
+
+		return files, nil
+	}
+
+	file, err := h.find(r.Filepath)
+	if err != nil {
+		return nil, err
+	}
+
+	return testFileLister{file}, nil
+}
+
+func Test_SftpGlobResource(t *testing.T) {
+	t.Parallel()
+
+	day := time.Date(2024, 8, 2, 0, 0, 0, 0, time.UTC)
+
+	handler := &testDirHandler{
+		dir: "/reports",
+		files: []*testFileInfo{
+			{name: "Report_20240801_v2.csv", data: "v2\n", modTime: day.Add(time.Hour)},
+			{name: "Report_20240801_v1.csv", data: "v1\n", modTime: day},
+			{name: "Report_20240801_v3.csv", data: "v3\n", modTime: day.Add(-time.Hour)},
+			{name: "Report_20240731_v1.csv", data: "old\n", modTime: day.Add(-24 * time.Hour)},
+			{name: "Report_20240801_archive.csv", dir: true},
+		},
+	}
+
+	address := newTestSftpServer(t, sftp.Handlers{
+		FileGet:  handler,
+		FilePut:  sftp.InMemHandler().FilePut,
+		FileCmd:  sftp.InMemHandler().FileCmd,
+		FileList: handler,
+	})
+
+	testCases := []struct {
+		name     string
+		resource func(client ISftpClient) *SftpGlobResource
+		expected string
+		notFound bool
+	}{
+		{
+			name: "glob",
+			resource: func(client ISftpClient) *SftpGlobResource {
+				return NewSftpGlobResource(slog.Default(), client, "/reports", "Report_20240801_*.csv")
+			},
+			expected: "v1\nv2\nv3\n",
+		},
+		{
+			name: "regexp",
+			resource: func(client ISftpClient) *SftpGlobResource {
+				return NewSftpRegexpResource(slog.Default(), client, "/reports", regexp.MustCompile(`^Report_\d{8}_v1\.csv$`))
+			},
+			expected: "old\nv1\n",
+		},
+		{
+			name: "newest only",
+			resource: func(client ISftpClient) *SftpGlobResource {
+				return NewSftpGlobResource(slog.Default(), client, "/reports", "Report_20240801_*.csv").WithNewestOnly()
+			},
+			expected: "v2\n",
+		},
+		{
+			name: "no match",
+			resource: func(client ISftpClient) *SftpGlobResource {
+				return NewSftpGlobResource(slog.Default(), client, "/reports", "Report_20240802_*.csv")
+			},
+			notFound: true,
+		},
+		{
+			name: "no directory",
+			resource: func(client ISftpClient) *SftpGlobResource {
+				return NewSftpGlobResource(slog.Default(), client, "/missing", "*.csv")
+			},
+			notFound: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			resource := tc.resource(newTestSftpClient(address))
+
+			ctx := context.Background()
+
+			err := resource.Open(ctx)
+
+			if tc.notFound {
+				if !errors.Is(err, fs.ErrNotExist) || batch.CodeOf(err) != batch.CodeNotFound {
+					t.Fatalf("expected not found, got %s: %v", batch.CodeOf(err), err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("failed to open resource: %v", err)
+			}
+
+			defer resource.Close(ctx)
+
+			if size := resource.Size(); size != int64(len(tc.expected)) {
+				t.Fatalf("expected size %d, got %d", len(tc.expected), size)
+			}
+
+			var data strings.Builder
+
+			for {
+				page, err := io.ReadAll(resource)
+				if err != nil {
+					t.Fatalf("failed to read page: %v", err)
+				}
+
+				data.Write(page)
+
+				err = resource.NextPage(ctx)
+				if errors.Is(err, io.EOF) {
+					break
+				}
+
+				if err != nil {
+					t.Fatalf("failed to get next page: %v", err)
+				}
+			}
+
+			if diff := cmp.Diff(tc.expected, data.String()); diff != "" {
+				t.Fatalf("unexpected data (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_SftpGlobResource_InvalidPattern(t *testing.T) {
+	t.Parallel()
+
+	// fails before connecting, the client is not used
+	err := NewSftpGlobResource(slog.Default(), nil, "/reports", "Report_[2024*.csv").Open(context.Background())
+
+	var argErr *batch.IllegalArgumentError
+	if !errors.As(err, &argErr) || !errors.Is(err, path.ErrBadPattern) {
+		t.Fatalf("expected illegal pattern, got %v", err)
+	}
+}
+
+func Test_SftpGlobResource_NextPageFailed(t *testing.T) {
+	t.Parallel()
+
+	handler := &testDirHandler{
+		dir: "/reports",
+		files: []*testFileInfo{
+			{name: "Report_a.csv", data: "a\n"},
+			{name: "Report_b.csv", data: "b\n"},
+		},
+		unreadable: "Report_b.csv",
+	}
+
+	address := newTestSftpServer(t, sftp.Handlers{
+		FileGet:  handler,
+		FilePut:  sftp.InMemHandler().FilePut,
+		FileCmd:  sftp.InMemHandler().FileCmd,
+		FileList: handler,
+	})
+
+	ctx := context.Background()
+
+	resource := NewSftpGlobResource(slog.Default(), newTestSftpClient(address), "/reports", "Report_*.csv")
+
+	if err := resource.Open(ctx); err != nil {
+		t.Fatalf("failed to open resource: %v", err)
+	}
+
+	if data, err := io.ReadAll(resource); err != nil || string(data) != "a\n" {
+		t.Fatalf("unexpected first page %q, error: %v", data, err)
+	}
+
+	if err := resource.NextPage(ctx); err == nil || errors.Is(err, io.EOF) {
+		t.Fatalf("expected the second file to fail, got %v", err)
+	}
+
+	if _, err := resource.Read(make([]byte, 10)); !errors.Is(err, ErrResourceNotOpened) {
+		t.Fatalf("expected not opened, got %v", err)
+	}
+
+	// the first file is not closed twice
+	if err := resource.Close(ctx); err != nil {
+		t.Fatalf("failed to close resource: %v", err)
+	}
+}
@@ -0,0 +1,63 @@
+package resource
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+
+	"github.com/ivxivx/go-recon/batch"
+)
+
+// NewHostKeyCallbackFromKnownHosts verifies the host key of a server against known_hosts files, e.g. ~/.ssh/known_hosts.
+// A server which is not listed is rejected as well.
+func NewHostKeyCallbackFromKnownHosts(files ...string) (ssh.HostKeyCallback, error) {
+	if len(files) == 0 {
+		return nil, &batch.IllegalArgumentError{Name: "known hosts files"}
+	}
+
+	callback, err := knownhosts.New(files...)
+	if err != nil {
+		return nil, fmt.Errorf("could not read known hosts files %v: %w", files, err)
+	}
+
+	return callback, nil
+}
+
+// NewHostKeyCallbackFromFingerprints verifies the host key of a server against pinned fingerprints,
+// either SHA256 as printed by ssh-keygen -l, e.g. SHA256:uNiVztksCsDhcc0u9e8BujQXVUpKZIDTMczCvj3tD2s,
+// or legacy MD5 as printed by ssh-keygen -l -E md5, e.g. MD5:16:27:ac:a5:76:28:2d:36:63:1b:56:4d:eb:df:a6:48,
+// with or without the MD5: prefix.
+func NewHostKeyCallbackFromFingerprints(fingerprints ...string) (ssh.HostKeyCallback, error) {
+	if len(fingerprints) == 0 {
+		return nil, &batch.IllegalArgumentError{Name: "host key fingerprints"}
+	}
+
+	pinned := make(map[string]bool, len(fingerprints))
+
+	for _, fingerprint := range fingerprints {
+		fingerprint = strings.TrimSpace(fingerprint)
+
+		switch {
+		case strings.HasPrefix(fingerprint, "SHA256:"):
+			// base64 is case sensitive
+			pinned[fingerprint] = true
+		case strings.Count(strings.TrimPrefix(fingerprint, "MD5:"), ":") == 15:
+			pinned[strings.ToLower(strings.TrimPrefix(fingerprint, "MD5:"))] = true
+		default:
+			return nil, &batch.IllegalArgumentError{Name: "host key fingerprint", Value: fingerprint}
+		}
+	}
+
+	return func(hostname string, _ net.Addr, key ssh.PublicKey) error {
+		fingerprint := ssh.FingerprintSHA256(key)
+
+		if pinned[fingerprint] || pinned[ssh.FingerprintLegacyMD5(key)] {
+			return nil
+		}
+
+		return &HostKeyMismatchError{Hostname: hostname, Fingerprint: fingerprint}
+	}, nil
+}
@@ -0,0 +1,275 @@
+package resource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+
+	"github.com/ivxivx/go-recon/batch"
+)
+
+func Test_SftpClient_HostKey(t *testing.T) {
+	t.Parallel()
+
+	hostKey := newTestHostKey(t)
+	otherKey := newTestHostKey(t)
+
+	config := &ssh.ServerConfig{NoClientAuth: true}
+	config.AddHostKey(hostKey)
+
+	address := newTestSftpServerWithConfig(t, config, sftp.InMemHandler())
+
+	knownHosts := func(key ssh.PublicKey) string {
+		file := filepath.Join(t.TempDir(), "known_hosts")
+
+		line := knownhosts.Line([]string{knownhosts.Normalize(address)}, key)
+		if err := os.WriteFile(file, []byte(line+"\n"), 0o600); err != nil {
+			t.Fatalf("failed to write known hosts: %v", err)
+		}
+
+		return file
+	}
+
+	testCases := []struct {
+		name     string
+		callback func() (ssh.HostKeyCallback, error)
+		mismatch bool
+	}{
+		{
+			name: "known host",
+			callback: func() (ssh.HostKeyCallback, error) {
+				return NewHostKeyCallbackFromKnownHosts(knownHosts(hostKey.PublicKey()))
+			},
+		},
+		{
+			name: "known host with another key",
+			callback: func() (ssh.HostKeyCallback, error) {
+				return NewHostKeyCallbackFromKnownHosts(knownHosts(otherKey.PublicKey()))
+			},
+			mismatch: true,
+		},
+		{
+			name: "pinned SHA256 fingerprint",
+			callback: func() (ssh.HostKeyCallback, error) {
+				return NewHostKeyCallbackFromFingerprints(ssh.FingerprintSHA256(hostKey.PublicKey()))
+			},
+		},
+		{
+			name: "pinned MD5 fingerprint",
+			callback: func() (ssh.HostKeyCallback, error) {
+				return NewHostKeyCallbackFromFingerprints(ssh.FingerprintSHA256(otherKey.PublicKey()), ssh.FingerprintLegacyMD5(hostKey.PublicKey()))
+			},
+		},
+		{
+			name: "pinned MD5 fingerprint with prefix",
+			callback: func() (ssh.HostKeyCallback, error) {
+				return NewHostKeyCallbackFromFingerprints("MD5:" + strings.ToUpper(ssh.FingerprintLegacyMD5(hostKey.PublicKey())))
+			},
+		},
+		{
+			name: "fingerprint not pinned",
+			callback: func() (ssh.HostKeyCallback, error) {
+				return NewHostKeyCallbackFromFingerprints(ssh.FingerprintSHA256(otherKey.PublicKey()))
+			},
+			mismatch: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			callback, err := tc.callback()
+			if err != nil {
+				t.Fatalf("failed to create host key callback: %v", err)
+			}
+
+			client := NewSftpClient(slog.Default(), NewSSHServer(address, &ssh.ClientConfig{
+				User:            "test",
+				HostKeyCallback: callback,
+			}))
+
+			ctx := context.Background()
+
+			err = client.Open(ctx)
+
+			if tc.mismatch {
+				var connErr *batch.ConnectionError
+				if !errors.As(err, &connErr) || batch.CodeOf(err) != batch.CodeHostKeyMismatch || !batch.IsPermanent(err) {
+					t.Fatalf("expected permanent host key mismatch, got %s: %v", batch.CodeOf(err), err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("failed to open client: %v", err)
+			}
+
+			defer client.Close(ctx)
+		})
+	}
+
+	t.Run("invalid fingerprint", func(t *testing.T) {
+		t.Parallel()
+
+		for _, fingerprint := range []string{"not a fingerprint", "MD5:16:27:ac:a5"} {
+			if _, err := NewHostKeyCallbackFromFingerprints(fingerprint); batch.CodeOf(err) != batch.CodeIllegalArgument {
+				t.Fatalf("expected illegal argument for %s, got %v", fingerprint, err)
+			}
+		}
+	})
+}
+
+func Test_SftpClient_Auth(t *testing.T) {
+	t.Parallel()
+
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			if conn.User() == "recon" && string(password) == "secret" {
+				return nil, nil
+			}
+
+			return nil, fmt.Errorf("wrong password")
+		},
+		KeyboardInteractiveCallback: func(conn ssh.ConnMetadata, challenge ssh.KeyboardInteractiveChallenge) (*ssh.Permissions, error) {
+			answers, err := challenge("", "", []string{"Password: ", "Verification code: "}, []bool{false, true})
+			if err == nil && answers[0] == "secret" && answers[1] == "123456" {
+				return nil, nil
+			}
+
+			return nil, fmt.Errorf("wrong answers")
+		},
+	}
+	config.AddHostKey(newTestHostKey(t))
+
+	address := newTestSftpServerWithConfig(t, config, sftp.InMemHandler())
+
+	testCases := []struct {
+		name       string
+		authMethod ssh.AuthMethod
+		rejected   bool
+	}{
+		{
+			name:       "password",
+			authMethod: NewAuthMethodFromPassword("secret"),
+		},
+		{
+			name:       "wrong password",
+			authMethod: NewAuthMethodFromPassword("guess"),
+			rejected:   true,
+		},
+		{
+			name:       "keyboard interactive",
+			authMethod: NewAuthMethodFromKeyboardInteractive(map[string]string{"password": "secret", "Verification Code": "123456"}),
+		},
+		{
+			name:       "keyboard interactive without answer",
+			authMethod: NewAuthMethodFromKeyboardInteractive(map[string]string{"password": "secret"}),
+			rejected:   true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			client := NewSftpClient(slog.Default(), NewSSHServer(address, &ssh.ClientConfig{
+				User:            "recon",
+				Auth:            []ssh.AuthMethod{tc.authMethod},
+				HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+			}))
+
+			ctx := context.Background()
+
+			err := client.Open(ctx)
+
+			if tc.rejected {
+				if code := batch.CodeOf(err); code != batch.CodeAuthentication {
+					t.Fatalf("expected authentication error, got %s: %v", code, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("failed to open client: %v", err)
+			}
+
+			defer client.Close(ctx)
+		})
+	}
+}
+
+func Test_SftpClient_Auth_ConnectionDropped(t *testing.T) {
+	t.Parallel()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	defer listener.Close()
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+
+		// the server goes away while the client authenticates
+		config := &ssh.ServerConfig{
+			PasswordCallback: func(_ ssh.ConnMetadata, _ []byte) (*ssh.Permissions, error) {
+				_ = conn.Close()
+
+				return nil, fmt.Errorf("connection dropped")
+			},
+		}
+		config.AddHostKey(newTestHostKey(t))
+
+		_, _, _, _ = ssh.NewServerConn(conn, config)
+	}()
+
+	client := NewSftpClient(slog.Default(), NewSSHServer(listener.Addr().String(), &ssh.ClientConfig{
+		User:            "recon",
+		Auth:            []ssh.AuthMethod{NewAuthMethodFromPassword("secret")},
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+	}))
+
+	err = client.Open(context.Background())
+	if err == nil {
+		t.Fatalf("expected the handshake to fail")
+	}
+
+	if code := batch.CodeOf(err); code == batch.CodeAuthentication || !batch.IsRetryable(err) {
+		t.Fatalf("expected a retryable error, got %s: %v", code, err)
+	}
+}
+
+func Test_NewAuthMethodFromKeyboardInteractive(t *testing.T) {
+	t.Parallel()
+
+	challenge, cok := NewAuthMethodFromKeyboardInteractive(map[string]string{"Password": "secret"}).(ssh.KeyboardInteractiveChallenge)
+	if !cok {
+		t.Fatalf("expected keyboard interactive challenge")
+	}
+
+	answers, err := challenge("", "", []string{"password: "}, []bool{false})
+	if err != nil || len(answers) != 1 || answers[0] != "secret" {
+		t.Fatalf("unexpected answers %v, error: %v", answers, err)
+	}
+
+	if _, err := challenge("", "", []string{"Password:", "Verification code:"}, []bool{false, true}); !errors.Is(err, ErrNoAnswer) {
+		t.Fatalf("expected no answer, got %v", err)
+	}
+}
@@ -25,6 +25,8 @@ type SftpResource struct {
 	closeOnce sync.Once
 
 	file *sftp.File
+	size int64
+	ctx  context.Context // bounds reads and writes, which have no context
 }
 
 func NewSftpResource(logger *slog.Logger, client ISftpClient, filePath string) *SftpResource {
@@ -46,12 +48,22 @@ var (
 	_ batch.Resource = (*SftpResource)(nil)
 	_ io.Reader      = (*SftpResource)(nil)
 	_ io.Writer      = (*SftpResource)(nil)
+	_ batch.Sizer    = (*SftpResource)(nil)
 )
 
 func (r *SftpResource) GetID() string {
 	return r.filePath
 }
 
+func (r *SftpResource) Size() int64 {
+	if r.file == nil {
+		return -1
+	}
+
+	return r.size
+}
+
+// Open opens the file, the connection is dropped once ctx is done, so subsequent reads and writes fail.
 func (r *SftpResource) Open(ctx context.Context) error {
 	if r.file != nil {
 		r.logger.Warn("resource is already opened", slog.String("resource", r.filePath))
@@ -71,17 +83,31 @@ func (r *SftpResource) Open(ctx context.Context) error {
 
 		file, err := r.client.OpenFile(r.filePath, r.flag)
 		if err != nil {
-			errR = fmt.Errorf("could not open file %s via sftp: %w", r.filePath, err)
+			errR = fmt.Errorf("could not open file %s via sftp: %w", r.filePath, contextError(ctx, err))
 
 			return
 		}
 
+		r.size = -1
+
+		if info, errS := file.Stat(); errS == nil {
+			r.size = info.Size()
+		}
+
+		r.ctx = ctx
 		r.file = file
+		r.closeOnce = sync.Once{}
 	})
 
+	if errR != nil {
+		// a failed open can be retried
+		r.openOnce = sync.Once{}
+	}
+
 	return errR
 }
 
+// Close closes the file and the client, the connection is dropped once ctx is done.
 func (r *SftpResource) Close(ctx context.Context) error {
 	if r.file == nil {
 		r.logger.Info("resource is not opened, skip close", slog.String("resource", r.filePath))
@@ -93,18 +119,29 @@ func (r *SftpResource) Close(ctx context.Context) error {
 
 	r.closeOnce.Do(func() {
 		defer func() {
+			// the client is kept, e.g. a pooled client which is returned to the pool, so that the resource can be opened again
 			r.file = nil
-			r.client = nil
+			r.openOnce = sync.Once{}
 		}()
 
-		if err := r.file.Close(); err != nil {
-			errR = &batch.IoError{Operation: batch.IoClose, Resource: r.filePath, Err: err}
-		}
+		// closing the file waits for the server, closing the client below drops the connection if ctx is done
+		done := make(chan error, 1)
 
-		if r.client != nil {
-			if errC := r.client.Close(ctx); errC != nil {
-				r.logger.Warn("failed to close sftp client", slog.String("resource", r.filePath), slog.Any("error", errC))
+		go func(file *sftp.File) {
+			done <- file.Close()
+		}(r.file)
+
+		select {
+		case err := <-done:
+			if err != nil {
+				errR = &batch.IoError{Operation: batch.IoClose, Resource: r.filePath, Err: err}
 			}
+		case <-ctx.Done():
+			errR = &batch.IoError{Operation: batch.IoClose, Resource: r.filePath, Err: ctx.Err()}
+		}
+
+		if errC := r.client.Close(ctx); errC != nil {
+			r.logger.Warn("failed to close sftp client", slog.String("resource", r.filePath), slog.Any("error", errC))
 		}
 	})
 
@@ -116,13 +153,23 @@ func (r *SftpResource) Read(p []byte) (n int, err error) {
 		return 0, &batch.IoError{Operation: batch.IoRead, Resource: r.filePath, Err: ErrResourceNotOpened}
 	}
 
+	if errC := r.ctx.Err(); errC != nil {
+		return 0, &batch.IoError{Operation: batch.IoRead, Resource: r.filePath, Err: errC}
+	}
+
+	end, errB := beginSftpOperation(r.client)
+	if errB != nil {
+		return 0, &batch.IoError{Operation: batch.IoRead, Resource: r.filePath, Err: errB}
+	}
+	defer end()
+
 	n, err = r.file.Read(p)
 
 	if err == nil || errors.Is(err, io.EOF) {
 		return n, err
 	}
 
-	return 0, &batch.IoError{Operation: batch.IoRead, Resource: r.filePath, Err: err}
+	return 0, &batch.IoError{Operation: batch.IoRead, Resource: r.filePath, Err: contextError(r.ctx, err)}
 }
 
 func (r *SftpResource) Write(p []byte) (n int, err error) {
@@ -130,10 +177,20 @@ func (r *SftpResource) Write(p []byte) (n int, err error) {
 		return 0, &batch.IoError{Operation: batch.IoRead, Resource: r.filePath, Err: ErrResourceNotOpened}
 	}
 
+	if errC := r.ctx.Err(); errC != nil {
+		return 0, &batch.IoError{Operation: batch.IoWrite, Resource: r.filePath, Err: errC}
+	}
+
+	end, errB := beginSftpOperation(r.client)
+	if errB != nil {
+		return 0, &batch.IoError{Operation: batch.IoWrite, Resource: r.filePath, Err: errB}
+	}
+	defer end()
+
 	n, err = r.file.Write(p)
 	if err == nil {
 		return n, nil
 	}
 
-	return 0, &batch.IoError{Operation: batch.IoWrite, Resource: r.filePath, Err: err}
+	return 0, &batch.IoError{Operation: batch.IoWrite, Resource: r.filePath, Err: contextError(r.ctx, err)}
 }
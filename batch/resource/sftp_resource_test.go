@@ -0,0 +1,380 @@
+package resource
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/ivxivx/go-recon/batch"
+)
+
+const testTimeout = 5 * time.Second
+
+// stalledReaderAt blocks reads until released, like a stalled server.
+type stalledReaderAt struct {
+	release chan struct{}
+}
+
+func (r *stalledReaderAt) ReadAt(_ []byte, _ int64) (int, error) {
+	<-r.release
+
+	return 0, io.EOF
+}
+
+type testFileInfo struct {
+	name    string
+	data    string
+	modTime time.Time
+	dir     bool
+}
+
+func (fi *testFileInfo) Name() string       { return fi.name }
+func (fi *testFileInfo) Size() int64        { return int64(len(fi.data)) }
+func (fi *testFileInfo) ModTime() time.Time { return fi.modTime }
+func (fi *testFileInfo) IsDir() bool        { return fi.dir }
+func (fi *testFileInfo) Sys() any           { return nil }
+
+func (fi *testFileInfo) Mode() fs.FileMode {
+	if fi.dir {
+		return fs.ModeDir | 0o755
+	}
+
+	return 0o644
+}
+
+type testFileLister []os.FileInfo
+
+func (l testFileLister) ListAt(ls []os.FileInfo, offset int64) (int, error) {
+	if offset >= int64(len(l)) {
+		return 0, io.EOF
+	}
+
+	n := copy(ls, l[offset:])
+	if n < len(ls) {
+		return n, io.EOF
+	}
+
+	return n, nil
+}
+
+type stalledHandler struct {
+	reader *stalledReaderAt
+}
+
+func (h *stalledHandler) Fileread(_ *sftp.Request) (io.ReaderAt, error) {
+	return h.reader, nil
+}
+
+func (h *stalledHandler) Filelist(r *sftp.Request) (sftp.ListerAt, error) {
+	// the reads of the file stall, only its size matters
+	return testFileLister{&testFileInfo{name: r.Filepath, data: strings.Repeat("x", 100)}}, nil
+}
+
+func newTestHostKey(t *testing.T) ssh.Signer {
+	t.Helper()
+
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate host key: %v", err)
+	}
+
+	hostKey, err := ssh.NewSignerFromKey(privateKey)
+	if err != nil {
+		t.Fatalf("failed to create host key signer: %v", err)
+	}
+
+	return hostKey
+}
+
+// newTestSftpServer starts an in-process sftp server, whose files are served by the given handlers.
+func newTestSftpServer(t *testing.T, handlers sftp.Handlers) string {
+	t.Helper()
+
+	config := &ssh.ServerConfig{NoClientAuth: true}
+	config.AddHostKey(newTestHostKey(t))
+
+	return newTestSftpServerWithConfig(t, config, handlers)
+}
+
+// newTestSftpServerWithConfig starts an in-process sftp server with the given host key and authentication.
+func newTestSftpServerWithConfig(t *testing.T, config *ssh.ServerConfig, handlers sftp.Handlers) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	var wg sync.WaitGroup
+
+	t.Cleanup(func() {
+		listener.Close()
+		wg.Wait()
+	})
+
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				serveSftp(conn, config, handlers)
+			}()
+		}
+	}()
+
+	return listener.Addr().String()
+}
+
+func serveSftp(conn net.Conn, config *ssh.ServerConfig, handlers sftp.Handlers) {
+	defer conn.Close()
+
+	_, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+
+	go ssh.DiscardRequests(reqs)
+
+	for newChannel := range chans {
+		if newChannel.ChannelType() != "session" {
+			_ = newChannel.Reject(ssh.UnknownChannelType, "unknown channel type")
+
+			continue
+		}
+
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			return
+		}
+
+		go func() {
+			for req := range requests {
+				// payload of subsystem request is a length-prefixed string
+				_ = req.Reply(req.Type == "subsystem" && string(req.Payload[4:]) == "sftp", nil)
+			}
+		}()
+
+		server := sftp.NewRequestServer(channel, handlers)
+
+		go func() {
+			_ = server.Serve()
+			server.Close()
+		}()
+	}
+}
+
+func newTestSftpClient(address string) *SftpClient {
+	return NewSftpClient(slog.Default(), NewSSHServer(address, &ssh.ClientConfig{
+		User:            "test",
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+	}))
+}
+
+func Test_SftpClient_Open_StalledServer(t *testing.T) {
+	t.Parallel()
+
+	// accepts connections but never completes the ssh handshake
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	// closed once the parallel subtests are done
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		var conns []net.Conn
+
+		defer func() {
+			for _, conn := range conns {
+				conn.Close()
+			}
+		}()
+
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			conns = append(conns, conn)
+		}
+	}()
+
+	testCases := []struct {
+		name             string
+		timeout          time.Duration // of the ctx of Open, 0 if none
+		handshakeTimeout time.Duration
+	}{
+		{name: "ctx deadline", timeout: 100 * time.Millisecond},
+		{name: "handshake timeout", handshakeTimeout: 100 * time.Millisecond},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+
+			if tc.timeout > 0 {
+				var cancel context.CancelFunc
+
+				ctx, cancel = context.WithTimeout(ctx, tc.timeout)
+				defer cancel()
+			}
+
+			client := newTestSftpClient(listener.Addr().String()).WithHandshakeTimeout(tc.handshakeTimeout)
+
+			done := make(chan error, 1)
+
+			go func() {
+				done <- client.Open(ctx)
+			}()
+
+			select {
+			case err := <-done:
+				var connErr *batch.ConnectionError
+				if !errors.As(err, &connErr) {
+					t.Fatalf("expected connection error, got %v", err)
+				}
+
+				if !errors.Is(err, context.DeadlineExceeded) {
+					t.Fatalf("expected deadline exceeded, got %v", err)
+				}
+			case <-time.After(testTimeout):
+				t.Fatalf("open did not return after the deadline")
+			}
+		})
+	}
+}
+
+func Test_SftpClient_Open_HandshakeTimeoutElapsed(t *testing.T) {
+	t.Parallel()
+
+	handlers := sftp.InMemHandler()
+	address := newTestSftpServer(t, handlers)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client := newTestSftpClient(address).WithHandshakeTimeout(50 * time.Millisecond)
+
+	if err := client.Open(ctx); err != nil {
+		t.Fatalf("failed to open client: %v", err)
+	}
+
+	defer client.Close(ctx)
+
+	// the connection outlives the handshake timeout
+	time.Sleep(100 * time.Millisecond)
+
+	if _, err := client.ReadDir("/"); err != nil {
+		t.Fatalf("failed to read dir after the handshake timeout: %v", err)
+	}
+}
+
+func Test_SftpClient_Open_NilConfig(t *testing.T) {
+	t.Parallel()
+
+	// fails to open, not to construct
+	client := NewSftpClient(slog.Default(), NewSSHServer("127.0.0.1:22", nil))
+
+	err := client.Open(context.Background())
+
+	var argErr *batch.IllegalArgumentError
+	if !errors.As(err, &argErr) || !errors.Is(err, ErrNoSSHConfig) {
+		t.Fatalf("expected illegal config, got %v", err)
+	}
+}
+
+func Test_SftpResource_Read_StalledServer(t *testing.T) {
+	t.Parallel()
+
+	handler := &stalledHandler{reader: &stalledReaderAt{release: make(chan struct{})}}
+	defer close(handler.reader.release)
+
+	address := newTestSftpServer(t, sftp.Handlers{
+		FileGet:  handler,
+		FilePut:  sftp.InMemHandler().FilePut,
+		FileCmd:  sftp.InMemHandler().FileCmd,
+		FileList: handler,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	resource := NewSftpResource(slog.Default(), newTestSftpClient(address), "/report.csv")
+
+	if err := resource.Open(ctx); err != nil {
+		t.Fatalf("failed to open resource: %v", err)
+	}
+
+	if size := resource.Size(); size != 100 {
+		t.Fatalf("expected size 100, got %d", size)
+	}
+
+	done := make(chan error, 1)
+
+	go func() {
+		_, err := resource.Read(make([]byte, 10))
+		done <- err
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-done:
+		var ioErr *batch.IoError
+		if !errors.As(err, &ioErr) || ioErr.Operation != batch.IoRead {
+			t.Fatalf("expected read error, got %v", err)
+		}
+
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected canceled, got %v", err)
+		}
+	case <-time.After(testTimeout):
+		t.Fatalf("read did not return after cancellation")
+	}
+
+	closeCtx, closeCancel := context.WithTimeout(context.Background(), time.Second)
+	defer closeCancel()
+
+	closed := make(chan struct{})
+
+	go func() {
+		_ = resource.Close(closeCtx)
+
+		close(closed)
+	}()
+
+	select {
+	case <-closed:
+	case <-time.After(testTimeout):
+		t.Fatalf("close did not return")
+	}
+}
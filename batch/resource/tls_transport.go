@@ -0,0 +1,158 @@
+package resource
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/ivxivx/go-recon/batch"
+)
+
+// TLSConfig configures the TLS of the connections to a server, e.g. a bank API which requires client certificates.
+type TLSConfig struct {
+	// client certificate and key, either from files or PEM bytes
+	CertFile string
+	KeyFile  string
+	CertPEM  []byte
+	KeyPEM   []byte
+	// root CAs which sign the server certificate, either from a file or PEM bytes, the system pool if empty
+	CAFile string
+	CAPEM  []byte
+	// MinVersion is the minimum TLS version, e.g. tls.VersionTLS13, default is TLS 1.2
+	MinVersion uint16
+	// PinnedFingerprints are SHA-256 fingerprints of server certificates in hex, e.g. "ab:cd:..." or "abcd...".
+	// If not empty, the certificate of the server must match one of them, in addition to a valid chain.
+	PinnedFingerprints []string
+}
+
+// NewTLSTransport returns a transport like http.DefaultTransport with the TLS config.
+func NewTLSTransport(config *TLSConfig) (*http.Transport, error) {
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if config.MinVersion != 0 {
+		tlsConfig.MinVersion = config.MinVersion
+	}
+
+	certificate, err := loadClientCertificate(config)
+	if err != nil {
+		return nil, err
+	}
+
+	if certificate != nil {
+		tlsConfig.Certificates = []tls.Certificate{*certificate}
+	}
+
+	rootCAs, err := loadRootCAs(config)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig.RootCAs = rootCAs
+
+	if len(config.PinnedFingerprints) > 0 {
+		pinned := make(map[string]bool, len(config.PinnedFingerprints))
+		for _, fingerprint := range config.PinnedFingerprints {
+			pinned[normalizeFingerprint(fingerprint)] = true
+		}
+
+		// called after the chain is verified
+		tlsConfig.VerifyConnection = func(state tls.ConnectionState) error {
+			if len(state.PeerCertificates) == 0 {
+				return &CertificatePinError{}
+			}
+
+			sum := sha256.Sum256(state.PeerCertificates[0].Raw)
+			fingerprint := hex.EncodeToString(sum[:])
+
+			if !pinned[fingerprint] {
+				return &CertificatePinError{Fingerprint: fingerprint}
+			}
+
+			return nil
+		}
+	}
+
+	transport := &http.Transport{Proxy: http.ProxyFromEnvironment}
+
+	if defaultTransport, cok := http.DefaultTransport.(*http.Transport); cok {
+		transport = defaultTransport.Clone()
+	}
+
+	transport.TLSClientConfig = tlsConfig
+
+	return transport, nil
+}
+
+func loadClientCertificate(config *TLSConfig) (*tls.Certificate, error) {
+	certPEM, keyPEM := config.CertPEM, config.KeyPEM
+
+	if config.CertFile != "" || config.KeyFile != "" {
+		var err error
+
+		if certPEM, err = readPEMFile(config.CertFile); err != nil {
+			return nil, err
+		}
+
+		if keyPEM, err = readPEMFile(config.KeyFile); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(certPEM) == 0 && len(keyPEM) == 0 {
+		return nil, nil
+	}
+
+	certificate, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("could not load client certificate: %w", err)
+	}
+
+	return &certificate, nil
+}
+
+func loadRootCAs(config *TLSConfig) (*x509.CertPool, error) {
+	caPEM := config.CAPEM
+
+	if config.CAFile != "" {
+		var err error
+
+		if caPEM, err = readPEMFile(config.CAFile); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(caPEM) == 0 {
+		return nil, nil
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return nil, &batch.IllegalArgumentError{Name: "CA", Value: config.CAFile}
+	}
+
+	return pool, nil
+}
+
+func readPEMFile(file string) ([]byte, error) {
+	if file == "" {
+		return nil, &batch.IllegalArgumentError{Name: "PEM file"}
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, &batch.IoError{Operation: batch.IoRead, Resource: file, Err: err}
+	}
+
+	return data, nil
+}
+
+func normalizeFingerprint(fingerprint string) string {
+	return strings.ToLower(strings.ReplaceAll(fingerprint, ":", ""))
+}
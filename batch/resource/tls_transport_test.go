@@ -0,0 +1,210 @@
+package resource
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ivxivx/go-recon/batch"
+)
+
+// newTestClientCertificate returns a CA and a client certificate signed by it, in PEM.
+func newTestClientCertificate(t *testing.T) (caPEM []byte, certPEM []byte, keyPEM []byte) {
+	t.Helper()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate CA key: %v", err)
+	}
+
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("failed to create CA certificate: %v", err)
+	}
+
+	clientKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate client key: %v", err)
+	}
+
+	clientTemplate := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "recon"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	clientDER, err := x509.CreateCertificate(rand.Reader, clientTemplate, caTemplate, &clientKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("failed to create client certificate: %v", err)
+	}
+
+	clientKeyDER, err := x509.MarshalECPrivateKey(clientKey)
+	if err != nil {
+		t.Fatalf("failed to marshal client key: %v", err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER}),
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: clientDER}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: clientKeyDER})
+}
+
+func Test_NewTLSTransport(t *testing.T) {
+	t.Parallel()
+
+	clientCAPEM, clientCertPEM, clientKeyPEM := newTestClientCertificate(t)
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AppendCertsFromPEM(clientCAPEM)
+
+	// the server requires a client certificate signed by the client CA, and supports TLS 1.2 only
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) == 0 || r.TLS.PeerCertificates[0].Subject.CommonName != "recon" {
+			t.Errorf("unexpected client certificate")
+		}
+
+		_, _ = w.Write([]byte(`[]`))
+	}))
+	server.TLS = &tls.Config{
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  clientCAs,
+		MinVersion: tls.VersionTLS12,
+		MaxVersion: tls.VersionTLS12,
+	}
+	server.StartTLS()
+
+	// subtests run in parallel after this function returns
+	t.Cleanup(server.Close)
+
+	serverCAPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+
+	sum := sha256.Sum256(server.Certificate().Raw)
+	fingerprint := hex.EncodeToString(sum[:])
+
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key")
+
+	if err := os.WriteFile(certFile, clientCertPEM, 0o600); err != nil {
+		t.Fatalf("failed to write client certificate: %v", err)
+	}
+
+	if err := os.WriteFile(keyFile, clientKeyPEM, 0o600); err != nil {
+		t.Fatalf("failed to write client key: %v", err)
+	}
+
+	testCases := []struct {
+		name    string
+		config  *TLSConfig
+		wantErr bool
+		errCode batch.ErrorCode
+	}{
+		{
+			name:   "client certificate from PEM",
+			config: &TLSConfig{CertPEM: clientCertPEM, KeyPEM: clientKeyPEM, CAPEM: serverCAPEM},
+		},
+		{
+			name:   "client certificate from file",
+			config: &TLSConfig{CertFile: certFile, KeyFile: keyFile, CAPEM: serverCAPEM},
+		},
+		{
+			name:    "unknown CA",
+			config:  &TLSConfig{CertPEM: clientCertPEM, KeyPEM: clientKeyPEM},
+			errCode: batch.CodeAuthentication,
+		},
+		{
+			name: "pinned fingerprint",
+			config: &TLSConfig{
+				CertPEM: clientCertPEM, KeyPEM: clientKeyPEM, CAPEM: serverCAPEM,
+				PinnedFingerprints: []string{"00:11", fingerprint},
+			},
+		},
+		{
+			name: "fingerprint not pinned",
+			config: &TLSConfig{
+				CertPEM: clientCertPEM, KeyPEM: clientKeyPEM, CAPEM: serverCAPEM,
+				PinnedFingerprints: []string{"00:11"},
+			},
+			errCode: batch.CodeAuthentication,
+		},
+		{
+			name: "minimum version not supported by server",
+			config: &TLSConfig{
+				CertPEM: clientCertPEM, KeyPEM: clientKeyPEM, CAPEM: serverCAPEM,
+				MinVersion: tls.VersionTLS13,
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			resource, err := NewHTTPResourceWithTLS(slog.Default(), server.URL, nil, tc.config)
+			if err != nil {
+				t.Fatalf("failed to create resource: %v", err)
+			}
+
+			ctx := context.Background()
+
+			err = resource.Open(ctx)
+
+			if tc.wantErr && err == nil {
+				t.Fatalf("expected error")
+			}
+
+			if tc.wantErr {
+				return
+			}
+
+			if tc.errCode != "" {
+				if code := batch.CodeOf(err); code != tc.errCode {
+					t.Fatalf("expected error with code %s, got %s: %v", tc.errCode, code, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("failed to open resource: %v", err)
+			}
+
+			defer resource.Close(ctx)
+		})
+	}
+
+	t.Run("invalid CA", func(t *testing.T) {
+		t.Parallel()
+
+		if _, err := NewTLSTransport(&TLSConfig{CAPEM: []byte("not a certificate")}); err == nil {
+			t.Fatalf("expected error for invalid CA")
+		}
+	})
+}
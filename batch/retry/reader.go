@@ -0,0 +1,48 @@
+package retry
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/ivxivx/go-recon/batch"
+)
+
+// Reader retries opening the delegate reader, reading records is not retried as it is not idempotent.
+type Reader struct {
+	logger   *slog.Logger
+	delegate batch.Reader
+	policy   Policy
+}
+
+func NewReader(logger *slog.Logger, delegate batch.Reader, policy Policy) *Reader {
+	return &Reader{
+		logger:   logger,
+		delegate: delegate,
+		policy:   policy,
+	}
+}
+
+var (
+	_ batch.Reader           = (*Reader)(nil)
+	_ batch.ProgressReporter = (*Reader)(nil)
+)
+
+func (r *Reader) Open(ctx context.Context) error {
+	return Do(ctx, r.logger, r.policy, "open reader", r.delegate.Open)
+}
+
+func (r *Reader) Close(ctx context.Context) error {
+	return r.delegate.Close(ctx)
+}
+
+func (r *Reader) Read(ctx context.Context, record any) error {
+	return r.delegate.Read(ctx, record)
+}
+
+func (r *Reader) GetProgress() batch.Progress {
+	if reporter, cok := r.delegate.(batch.ProgressReporter); cok {
+		return reporter.GetProgress()
+	}
+
+	return batch.Progress{TotalBytes: -1}
+}
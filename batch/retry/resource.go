@@ -0,0 +1,81 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+
+	"github.com/ivxivx/go-recon/batch"
+)
+
+// Resource retries opening the delegate resource, reads and writes are not retried as they are not idempotent.
+type Resource struct {
+	logger   *slog.Logger
+	delegate batch.Resource
+	policy   Policy
+}
+
+func NewResource(logger *slog.Logger, delegate batch.Resource, policy Policy) *Resource {
+	return &Resource{
+		logger:   logger,
+		delegate: delegate,
+		policy:   policy,
+	}
+}
+
+var (
+	_ batch.ResourceReader = (*Resource)(nil)
+	_ batch.ResourceWriter = (*Resource)(nil)
+	_ batch.Sizer          = (*Resource)(nil)
+	_ batch.PagedResource  = (*Resource)(nil)
+)
+
+func (r *Resource) GetID() string {
+	return r.delegate.GetID()
+}
+
+func (r *Resource) Open(ctx context.Context) error {
+	return Do(ctx, r.logger, r.policy, "open "+r.delegate.GetID(), r.delegate.Open)
+}
+
+func (r *Resource) Close(ctx context.Context) error {
+	return r.delegate.Close(ctx)
+}
+
+func (r *Resource) Read(p []byte) (int, error) {
+	reader, cok := r.delegate.(batch.ResourceReader)
+	if !cok {
+		return 0, &batch.IoError{Operation: batch.IoRead, Resource: r.delegate.GetID(), Err: errors.ErrUnsupported}
+	}
+
+	return reader.Read(p)
+}
+
+func (r *Resource) Write(p []byte) (int, error) {
+	writer, cok := r.delegate.(batch.ResourceWriter)
+	if !cok {
+		return 0, &batch.IoError{Operation: batch.IoWrite, Resource: r.delegate.GetID(), Err: errors.ErrUnsupported}
+	}
+
+	return writer.Write(p)
+}
+
+// NextPage moves the delegate to its next page, it returns io.EOF if the delegate is not paged. It is not
+// retried, as a delegate may have moved past the page which failed.
+func (r *Resource) NextPage(ctx context.Context) error {
+	paged, cok := r.delegate.(batch.PagedResource)
+	if !cok {
+		return io.EOF
+	}
+
+	return paged.NextPage(ctx)
+}
+
+func (r *Resource) Size() int64 {
+	if sizer, cok := r.delegate.(batch.Sizer); cok {
+		return sizer.Size()
+	}
+
+	return -1
+}
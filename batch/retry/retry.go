@@ -0,0 +1,110 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"github.com/ivxivx/go-recon/batch"
+)
+
+const (
+	defaultMaxAttempts     = 3
+	defaultInitialInterval = 500 * time.Millisecond
+	defaultMaxInterval     = 10 * time.Second
+	defaultMultiplier      = 2
+	defaultJitter          = 0.2
+)
+
+// Policy is an exponential backoff policy, only errors classified as retryable by batch.IsRetryable are retried.
+type Policy struct {
+	MaxAttempts     int // including the first attempt
+	InitialInterval time.Duration
+	MaxInterval     time.Duration // bounds the backoff, including jitter and the Retry-After of the server
+	Multiplier      float64
+	Jitter          float64 // a fraction of the interval, which is randomly added or subtracted
+}
+
+func DefaultPolicy() Policy {
+	return Policy{
+		MaxAttempts:     defaultMaxAttempts,
+		InitialInterval: defaultInitialInterval,
+		MaxInterval:     defaultMaxInterval,
+		Multiplier:      defaultMultiplier,
+		Jitter:          defaultJitter,
+	}
+}
+
+// Backoff returns the interval to wait after the given attempt failed, attempts start from 1.
+func (p Policy) Backoff(attempt int) time.Duration {
+	interval := float64(p.InitialInterval) * math.Pow(p.Multiplier, float64(attempt-1))
+
+	if p.MaxInterval > 0 && interval > float64(p.MaxInterval) {
+		interval = float64(p.MaxInterval)
+	}
+
+	// the jitter may exceed the max interval again
+	if p.Jitter > 0 {
+		interval += interval * p.Jitter * (2*rand.Float64() - 1)
+	}
+
+	return p.clamp(time.Duration(interval))
+}
+
+func (p Policy) clamp(interval time.Duration) time.Duration {
+	if p.MaxInterval > 0 && interval > p.MaxInterval {
+		return p.MaxInterval
+	}
+
+	return interval
+}
+
+// Do calls fn until it succeeds, it fails with a permanent error, the attempts are exhausted or ctx is done.
+// The Retry-After of an InvalidStatusError takes precedence over the backoff of the policy, up to MaxInterval.
+// The error of the last attempt is returned.
+func Do(ctx context.Context, logger *slog.Logger, policy Policy, operation string, fn func(ctx context.Context) error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil {
+			return nil
+		}
+
+		if attempt >= policy.MaxAttempts || !batch.IsRetryable(err) {
+			return err
+		}
+
+		backoff := policy.Backoff(attempt)
+
+		// the server knows better when it is ready again
+		var statusErr *batch.InvalidStatusError
+		if errors.As(err, &statusErr) && statusErr.RetryAfter > 0 {
+			backoff = policy.clamp(statusErr.RetryAfter)
+		}
+
+		// no point to wait if the next attempt would start after the deadline
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < backoff {
+			return err
+		}
+
+		logger.Warn("operation failed, will retry",
+			slog.String("operation", operation),
+			slog.Int("attempt", attempt),
+			slog.Duration("backoff", backoff),
+			slog.String("code", string(batch.CodeOf(err))),
+			slog.Any("error", err),
+		)
+
+		timer := time.NewTimer(backoff)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			return err
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package retry_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ivxivx/go-recon/batch"
+	rs "github.com/ivxivx/go-recon/batch/resource"
+	"github.com/ivxivx/go-recon/batch/retry"
+)
+
+func testPolicy() retry.Policy {
+	return retry.Policy{
+		MaxAttempts:     3,
+		InitialInterval: time.Millisecond,
+		MaxInterval:     10 * time.Millisecond,
+		Multiplier:      2,
+	}
+}
+
+func Test_Resource_Open(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		statuses []int
+		attempts int32
+		err      bool
+	}{
+		{
+			name:     "recovers from unavailable",
+			statuses: []int{http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusOK},
+			attempts: 3,
+		},
+		{
+			name:     "gives up after max attempts",
+			statuses: []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusOK},
+			attempts: 3,
+			err:      true,
+		},
+		{
+			name:     "does not retry not found",
+			statuses: []int{http.StatusNotFound, http.StatusOK},
+			attempts: 1,
+			err:      true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var attempts atomic.Int32
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				attempt := attempts.Add(1)
+
+				w.WriteHeader(tc.statuses[attempt-1])
+				_, _ = w.Write([]byte("ok"))
+			}))
+			defer server.Close()
+
+			ctx := context.Background()
+
+			resource := retry.NewResource(slog.Default(), rs.NewHTTPResource(slog.Default(), server.URL), testPolicy())
+
+			err := resource.Open(ctx)
+			if tc.err != (err != nil) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if attempts.Load() != tc.attempts {
+				t.Fatalf("expected %d attempts, got %d", tc.attempts, attempts.Load())
+			}
+
+			if err != nil {
+				return
+			}
+
+			defer resource.Close(ctx)
+
+			data, err := io.ReadAll(resource)
+			if err != nil || string(data) != "ok" {
+				t.Fatalf("unexpected data %q, error: %v", data, err)
+			}
+		})
+	}
+}
+
+func Test_Resource_NextPage(t *testing.T) {
+	t.Parallel()
+
+	pages := []string{"a", "b", "c"}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("p"))
+
+		if page+1 < len(pages) {
+			w.Header().Set("Link", fmt.Sprintf(`</?p=%d>; rel="next"`, page+1))
+		}
+
+		_, _ = w.Write([]byte(pages[page]))
+	}))
+	t.Cleanup(server.Close)
+
+	testCases := []struct {
+		name     string
+		delegate batch.Resource
+		expected string
+	}{
+		{
+			name:     "paged",
+			delegate: rs.NewPaginatedHTTPResource(slog.Default(), rs.NewHTTPResource(slog.Default(), server.URL), &rs.LinkHeaderPaginator{}),
+			expected: "abc",
+		},
+		{
+			name:     "not paged",
+			delegate: rs.NewHTTPResource(slog.Default(), server.URL),
+			expected: "a",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+
+			resource := retry.NewResource(slog.Default(), tc.delegate, testPolicy())
+
+			if err := resource.Open(ctx); err != nil {
+				t.Fatalf("failed to open resource: %v", err)
+			}
+
+			defer resource.Close(ctx)
+
+			var data []byte
+
+			for {
+				page, err := io.ReadAll(resource)
+				if err != nil {
+					t.Fatalf("failed to read page: %v", err)
+				}
+
+				data = append(data, page...)
+
+				err = resource.NextPage(ctx)
+				if errors.Is(err, io.EOF) {
+					break
+				}
+
+				if err != nil {
+					t.Fatalf("failed to move to next page: %v", err)
+				}
+			}
+
+			if string(data) != tc.expected {
+				t.Fatalf("expected %q, got %q", tc.expected, data)
+			}
+		})
+	}
+}
+
+func Test_Do_Canceled(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	policy := testPolicy()
+	policy.InitialInterval = time.Hour
+
+	unavailable := &batch.InvalidStatusError{StatusCode: http.StatusServiceUnavailable}
+
+	err := retry.Do(ctx, slog.Default(), policy, "test", func(_ context.Context) error {
+		cancel()
+
+		return unavailable
+	})
+
+	if !errors.Is(err, unavailable) {
+		t.Fatalf("expected the error of the last attempt, got %v", err)
+	}
+}
+
+func Test_Do_RetryAfterClamped(t *testing.T) {
+	t.Parallel()
+
+	// the next attempt would start after the deadline, unless the Retry-After is clamped
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var attempts int
+
+	err := retry.Do(ctx, slog.Default(), testPolicy(), "test", func(_ context.Context) error {
+		attempts++
+
+		return &batch.InvalidStatusError{StatusCode: http.StatusTooManyRequests, RetryAfter: 24 * time.Hour}
+	})
+
+	if err == nil || attempts != testPolicy().MaxAttempts {
+		t.Fatalf("expected %d attempts, got %d, error: %v", testPolicy().MaxAttempts, attempts, err)
+	}
+}
+
+func Test_Policy_Backoff(t *testing.T) {
+	t.Parallel()
+
+	policy := retry.Policy{InitialInterval: time.Second, MaxInterval: 5 * time.Second, Multiplier: 2}
+
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second}
+
+	for i, backoff := range expected {
+		if actual := policy.Backoff(i + 1); actual != backoff {
+			t.Fatalf("expected backoff %s for attempt %d, got %s", backoff, i+1, actual)
+		}
+	}
+
+	policy.Jitter = 0.5
+
+	for range 100 {
+		if actual := policy.Backoff(4); actual > policy.MaxInterval {
+			t.Fatalf("expected backoff up to %s with jitter, got %s", policy.MaxInterval, actual)
+		}
+	}
+}
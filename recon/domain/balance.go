@@ -0,0 +1,21 @@
+package domain
+
+import "github.com/shopspring/decimal"
+
+// Balance is a balance record of a statement, e.g. the opening and closing balances of a bank account.
+type Balance interface {
+	GetAccount() string
+	GetCurrency() string
+	GetOpeningBalance() decimal.Decimal
+	GetClosingBalance() decimal.Decimal
+}
+
+// Movement is a transaction which changes the balance of an account.
+type Movement interface {
+	GetAccount() string
+	GetCurrency() string
+	// GetAmount returns a signed amount, credits are positive and debits are negative
+	GetAmount() decimal.Decimal
+	// IsSettled returns true if the movement is booked, e.g. a declined or pending transfer does not change the balance
+	IsSettled() bool
+}
@@ -0,0 +1,27 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+type BalanceReconResult struct {
+	ID             uuid.UUID        `json:"id"`
+	CreatedAt      time.Time        `json:"created_at"`
+	UpdatedAt      time.Time        `json:"updated_at"`
+	PartyID        string           `json:"party_id"`
+	Account        string           `json:"account"`
+	Currency       string           `json:"currency"`
+	ResultType     string           `json:"result_type"`
+	OpeningBalance *decimal.Decimal `json:"opening_balance"`
+	ClosingBalance *decimal.Decimal `json:"closing_balance"`
+	Movements      decimal.Decimal  `json:"movements"`
+	MovementCount  int              `json:"movement_count"`
+	Difference     *decimal.Decimal `json:"difference"` // closing_balance - (opening_balance + movements)
+}
+
+func NewBalanceReconResultID() uuid.UUID {
+	return uuid.Must(uuid.NewV7())
+}
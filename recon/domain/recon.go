@@ -10,3 +10,8 @@ type Transaction interface {
 	GetType() string
 	GetTimestamp() time.Time
 }
+
+// PendingTransaction is implemented by transactions which may be in a non-terminal state, e.g. processing.
+type PendingTransaction interface {
+	IsPending() bool
+}
@@ -17,6 +17,16 @@ const (
 
 type ItemKey string
 
+// ReasonCode explains why an item is not matched.
+type ReasonCode string
+
+const (
+	ReasonCodeMissingCounterpart    ReasonCode = "missing_counterpart"
+	ReasonCodeValueMismatch         ReasonCode = "value_mismatch"
+	ReasonCodeStatusMappingNotFound ReasonCode = "status_mapping_not_found"
+	ReasonCodeParseFailed           ReasonCode = "parse_failed"
+)
+
 type TxReconItem struct {
 	ID          uuid.UUID        `json:"id"`
 	CreatedAt   time.Time        `json:"created_at"`
@@ -28,6 +38,8 @@ type TxReconItem struct {
 	PartyValue1 *string          `json:"party_value1"`
 	PartyValue2 *string          `json:"party_value2"`
 	Difference  *decimal.Decimal `json:"difference"` // party2_value - party1_value, only for decimal values
+	ReasonCode  ReasonCode       `json:"reason_code,omitempty"`
+	Reason      string           `json:"reason,omitempty"` // human-readable explanation of the reason code
 }
 
 func NewTxReconItemID() uuid.UUID {
@@ -4,21 +4,43 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+type Resolution string
+
+const (
+	ResolutionWriteOff      Resolution = "write_off"
+	ResolutionFeeDifference Resolution = "fee_difference"
 )
 
 type TxReconResult struct {
-	ID                   uuid.UUID      `json:"id"`
-	CreatedAt            time.Time      `json:"created_at"`
-	UpdatedAt            time.Time      `json:"updated_at"`
-	MatchingKey          string         `json:"matching_key"`
-	ResultType           string         `json:"result_type"`
-	TransactionTimestamp time.Time      `json:"transaction_timestamp"`
-	TransactionType      string         `json:"transaction_type"`
-	PartyID1             string         `json:"party_id1"`
-	PartyID2             string         `json:"party_id2"`
-	PartyTransactionID1  *string        `json:"party_transaction_id1,omitempty"`
-	PartyTransactionID2  *string        `json:"party_transaction_id2,omitempty"`
-	Items                []*TxReconItem `json:"items,omitempty"`
+	ID                   uuid.UUID        `json:"id"`
+	CreatedAt            time.Time        `json:"created_at"`
+	UpdatedAt            time.Time        `json:"updated_at"`
+	MatchingKey          string           `json:"matching_key"`
+	ResultType           string           `json:"result_type"`
+	TransactionTimestamp time.Time        `json:"transaction_timestamp"`
+	TransactionType      string           `json:"transaction_type"`
+	PartyID1             string           `json:"party_id1"`
+	PartyID2             string           `json:"party_id2"`
+	PartyTransactionID1  *string          `json:"party_transaction_id1,omitempty"`
+	PartyTransactionID2  *string          `json:"party_transaction_id2,omitempty"`
+	Items                []*TxReconItem   `json:"items,omitempty"`
+	Resolution           *Resolution      `json:"resolution,omitempty"` // set by ops once the break is resolved
+	Severity             *decimal.Decimal `json:"severity,omitempty"`
+	UnscorableReason     string           `json:"unscorable_reason,omitempty"` // why severity is unknown
+}
+
+// FindItem returns the first item of the given type.
+func (r *TxReconResult) FindItem(itemType ItemType) (*TxReconItem, bool) {
+	for _, item := range r.Items {
+		if item.Type == string(itemType) {
+			return item, true
+		}
+	}
+
+	return nil, false
 }
 
 func NewTxReconResultID() uuid.UUID {
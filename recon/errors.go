@@ -10,3 +10,11 @@ type UnexpectedTypeError struct {
 func (e *UnexpectedTypeError) Error() string {
 	return fmt.Sprintf("cannot cast type from %T to %T", e.FromType, e.ToType)
 }
+
+type UnknownResultTypeError struct {
+	ResultType string
+}
+
+func (e *UnknownResultTypeError) Error() string {
+	return "unknown result type " + e.ResultType
+}
@@ -0,0 +1,21 @@
+package journal
+
+import "fmt"
+
+type UnmappedBreakTypeError struct {
+	MatchingKey string
+	Resolution  string
+	ItemType    string
+}
+
+func (e *UnmappedBreakTypeError) Error() string {
+	return fmt.Sprintf("no account mapping for %s of item type %s of %s", e.Resolution, e.ItemType, e.MatchingKey)
+}
+
+type MissingCurrencyError struct {
+	MatchingKey string
+}
+
+func (e *MissingCurrencyError) Error() string {
+	return "could not determine currency of " + e.MatchingKey
+}
@@ -0,0 +1,154 @@
+package journal
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/ivxivx/go-recon/batch"
+	"github.com/ivxivx/go-recon/recon/domain"
+)
+
+// Generator turns resolved recon results into balanced journal entries.
+type Generator struct {
+	logger      *slog.Logger
+	writer      batch.Writer
+	chart       ChartOfAccounts
+	postingDate *time.Time
+}
+
+func NewGenerator(
+	logger *slog.Logger,
+	writer batch.Writer,
+	chart ChartOfAccounts,
+) *Generator {
+	return &Generator{
+		logger: logger,
+		writer: writer,
+		chart:  chart,
+	}
+}
+
+// WithPostingDate sets the posting date of all entries, default is the transaction timestamp.
+func (g *Generator) WithPostingDate(postingDate time.Time) *Generator {
+	g.postingDate = &postingDate
+
+	return g
+}
+
+// Generate writes journal entries of resolved results to the writer, which must be opened by the caller.
+// Nothing is written unless all entries can be built. It returns the number of entries written,
+// if writing fails the entry being written may be incomplete.
+func (g *Generator) Generate(ctx context.Context, results []*domain.TxReconResult) (int, error) {
+	postings := make([]*Posting, 0, len(results)*2)
+
+	for _, result := range results {
+		resultPostings, err := g.build(result)
+		if err != nil {
+			return 0, err
+		}
+
+		postings = append(postings, resultPostings...)
+	}
+
+	for i, posting := range postings {
+		err := g.writer.Write(ctx, posting)
+		if err != nil {
+			return i / 2, err
+		}
+	}
+
+	return len(postings) / 2, nil
+}
+
+func (g *Generator) build(result *domain.TxReconResult) ([]*Posting, error) {
+	if result.Resolution == nil {
+		g.logger.Debug("result is not resolved, skip", slog.String("matching_key", result.MatchingKey))
+
+		return nil, nil
+	}
+
+	resolution := *result.Resolution
+
+	var postings []*Posting
+
+	for _, item := range result.Items {
+		if item.Difference == nil || item.Difference.IsZero() {
+			continue
+		}
+
+		mapping, found := g.chart[BreakType{Resolution: resolution, ItemType: domain.ItemType(item.Type)}]
+		if !found {
+			return nil, &UnmappedBreakTypeError{MatchingKey: result.MatchingKey, Resolution: string(resolution), ItemType: item.Type}
+		}
+
+		currency, found := findCurrency(result)
+		if !found {
+			return nil, &MissingCurrencyError{MatchingKey: result.MatchingKey}
+		}
+
+		debitAccount, creditAccount := mapping.DebitAccount, mapping.CreditAccount
+		if item.Difference.IsNegative() {
+			debitAccount, creditAccount = creditAccount, debitAccount
+		}
+
+		amount := item.Difference.Abs()
+
+		postingDate := result.TransactionTimestamp
+		if g.postingDate != nil {
+			postingDate = *g.postingDate
+		}
+
+		entryID := NewJournalEntryID()
+		description := fmt.Sprintf("%s of %s difference", resolution, item.Key)
+
+		postings = append(postings,
+			&Posting{
+				EntryID:     entryID,
+				LineNumber:  1,
+				PostingDate: postingDate.Format(time.DateOnly),
+				Account:     debitAccount,
+				Debit:       amount,
+				Credit:      decimal.Zero,
+				Currency:    currency,
+				MatchingKey: result.MatchingKey,
+				Resolution:  string(resolution),
+				Description: description,
+			},
+			&Posting{
+				EntryID:     entryID,
+				LineNumber:  2,
+				PostingDate: postingDate.Format(time.DateOnly),
+				Account:     creditAccount,
+				Debit:       decimal.Zero,
+				Credit:      amount,
+				Currency:    currency,
+				MatchingKey: result.MatchingKey,
+				Resolution:  string(resolution),
+				Description: description,
+			},
+		)
+	}
+
+	return postings, nil
+}
+
+func findCurrency(result *domain.TxReconResult) (string, bool) {
+	item, found := result.FindItem(domain.ItemTypeCurrency)
+	if !found {
+		return "", false
+	}
+
+	if item.PartyValue1 != nil {
+		return *item.PartyValue1, true
+	}
+
+	if item.PartyValue2 != nil {
+		return *item.PartyValue2, true
+	}
+
+	return "", false
+}
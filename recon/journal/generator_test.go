@@ -0,0 +1,163 @@
+package journal
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/ivxivx/go-recon/recon"
+	"github.com/ivxivx/go-recon/recon/domain"
+)
+
+var errTestWrite = errors.New("disk full")
+
+// testWriter collects the postings, it fails once failAt postings are written.
+type testWriter struct {
+	postings []*Posting
+	failAt   int
+}
+
+func (w *testWriter) Open(_ context.Context) error {
+	return nil
+}
+
+func (w *testWriter) Close(_ context.Context) error {
+	return nil
+}
+
+func (w *testWriter) Write(_ context.Context, record any) error {
+	if w.failAt > 0 && len(w.postings) >= w.failAt {
+		return errTestWrite
+	}
+
+	w.postings = append(w.postings, record.(*Posting))
+
+	return nil
+}
+
+func newTestResult(matchingKey string, resolution *domain.Resolution, difference string) *domain.TxReconResult {
+	return &domain.TxReconResult{
+		MatchingKey:          matchingKey,
+		ResultType:           recon.ResultAmountMismatched,
+		TransactionTimestamp: time.Date(2024, 8, 1, 13, 45, 22, 0, time.UTC),
+		Resolution:           resolution,
+		Items: []*domain.TxReconItem{
+			{Type: string(domain.ItemTypeCurrency), Key: "currency", PartyValue1: ptr("USD"), PartyValue2: ptr("USD"), Matched: true},
+			{Type: string(domain.ItemTypeAmount), Key: "amount", Difference: ptr(decimal.RequireFromString(difference))},
+		},
+	}
+}
+
+func Test_Generator(t *testing.T) {
+	t.Parallel()
+
+	chart := ChartOfAccounts{
+		{Resolution: domain.ResolutionWriteOff, ItemType: domain.ItemTypeAmount}:      {DebitAccount: "6100-writeoff", CreditAccount: "1200-clearing"},
+		{Resolution: domain.ResolutionFeeDifference, ItemType: domain.ItemTypeAmount}: {DebitAccount: "6200-fees", CreditAccount: "1200-clearing"},
+	}
+
+	testCases := []struct {
+		name     string
+		results  []*domain.TxReconResult
+		failAt   int
+		count    int
+		postings []*Posting
+		err      error
+	}{
+		{
+			name: "positive and negative differences",
+			results: []*domain.TxReconResult{
+				newTestResult("k1", ptr(domain.ResolutionWriteOff), "10.00"),
+				newTestResult("k2", ptr(domain.ResolutionFeeDifference), "-2.50"),
+			},
+			count: 2,
+			postings: []*Posting{
+				{LineNumber: 1, PostingDate: "2024-08-01", Account: "6100-writeoff", Debit: decimal.RequireFromString("10.00"), Credit: decimal.Zero, Currency: "USD", MatchingKey: "k1", Resolution: "write_off", Description: "write_off of amount difference"},
+				{LineNumber: 2, PostingDate: "2024-08-01", Account: "1200-clearing", Debit: decimal.Zero, Credit: decimal.RequireFromString("10.00"), Currency: "USD", MatchingKey: "k1", Resolution: "write_off", Description: "write_off of amount difference"},
+				{LineNumber: 1, PostingDate: "2024-08-01", Account: "1200-clearing", Debit: decimal.RequireFromString("2.50"), Credit: decimal.Zero, Currency: "USD", MatchingKey: "k2", Resolution: "fee_difference", Description: "fee_difference of amount difference"},
+				{LineNumber: 2, PostingDate: "2024-08-01", Account: "6200-fees", Debit: decimal.Zero, Credit: decimal.RequireFromString("2.50"), Currency: "USD", MatchingKey: "k2", Resolution: "fee_difference", Description: "fee_difference of amount difference"},
+			},
+		},
+		{
+			name: "unresolved and zero difference skipped",
+			results: []*domain.TxReconResult{
+				newTestResult("k1", nil, "10.00"),
+				newTestResult("k2", ptr(domain.ResolutionWriteOff), "0"),
+			},
+		},
+		{
+			name: "unmapped break type writes nothing",
+			results: []*domain.TxReconResult{
+				newTestResult("k1", ptr(domain.ResolutionWriteOff), "10.00"),
+				{
+					MatchingKey: "k2",
+					Resolution:  ptr(domain.ResolutionWriteOff),
+					Items:       []*domain.TxReconItem{{Type: "fee", Key: "fee", Difference: ptr(decimal.RequireFromString("1.00"))}},
+				},
+			},
+			err: &UnmappedBreakTypeError{MatchingKey: "k2", Resolution: "write_off", ItemType: "fee"},
+		},
+		{
+			name: "write failure returns entries written",
+			results: []*domain.TxReconResult{
+				newTestResult("k1", ptr(domain.ResolutionWriteOff), "10.00"),
+				newTestResult("k2", ptr(domain.ResolutionWriteOff), "20.00"),
+			},
+			failAt: 3,
+			count:  1,
+			postings: []*Posting{
+				{LineNumber: 1, PostingDate: "2024-08-01", Account: "6100-writeoff", Debit: decimal.RequireFromString("10.00"), Credit: decimal.Zero, Currency: "USD", MatchingKey: "k1", Resolution: "write_off", Description: "write_off of amount difference"},
+				{LineNumber: 2, PostingDate: "2024-08-01", Account: "1200-clearing", Debit: decimal.Zero, Credit: decimal.RequireFromString("10.00"), Currency: "USD", MatchingKey: "k1", Resolution: "write_off", Description: "write_off of amount difference"},
+				{LineNumber: 1, PostingDate: "2024-08-01", Account: "6100-writeoff", Debit: decimal.RequireFromString("20.00"), Credit: decimal.Zero, Currency: "USD", MatchingKey: "k2", Resolution: "write_off", Description: "write_off of amount difference"},
+			},
+			err: errTestWrite,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			writer := &testWriter{failAt: tc.failAt}
+
+			count, err := NewGenerator(slog.Default(), writer, chart).Generate(context.Background(), tc.results)
+
+			if tc.err != nil {
+				if err == nil || err.Error() != tc.err.Error() {
+					t.Fatalf("expected error %v, got %v", tc.err, err)
+				}
+			} else if err != nil {
+				t.Fatalf("failed to generate: %v", err)
+			}
+
+			if count != tc.count {
+				t.Fatalf("expected %d entries, got %d", tc.count, count)
+			}
+
+			// lines of an entry share the entry ID
+			for i := 1; i < len(writer.postings); i += 2 {
+				if writer.postings[i].EntryID != writer.postings[i-1].EntryID {
+					t.Fatalf("lines of entry %d have different IDs", i/2)
+				}
+			}
+
+			for _, posting := range writer.postings {
+				posting.EntryID = uuid.Nil
+			}
+
+			if diff := cmp.Diff(tc.postings, writer.postings); diff != "" {
+				t.Fatalf("unexpected postings (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func ptr[T any](s T) *T {
+	return &s
+}
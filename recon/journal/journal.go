@@ -0,0 +1,44 @@
+package journal
+
+import (
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/ivxivx/go-recon/recon/domain"
+)
+
+// Posting is one line of a double-entry journal entry, in the format of the ledger importer.
+type Posting struct {
+	EntryID     uuid.UUID       `csv:"entry_id"`
+	LineNumber  int             `csv:"line_number"`
+	PostingDate string          `csv:"posting_date"`
+	Account     string          `csv:"account"`
+	Debit       decimal.Decimal `csv:"debit"`
+	Credit      decimal.Decimal `csv:"credit"`
+	Currency    string          `csv:"currency"`
+	MatchingKey string          `csv:"matching_key"`
+	Resolution  string          `csv:"resolution"`
+	Description string          `csv:"description"`
+}
+
+// AccountMapping defines the accounts to post a difference to.
+// When party2 value is greater than party1 value (positive difference), DebitAccount is debited
+// and CreditAccount is credited, otherwise the other way round.
+type AccountMapping struct {
+	DebitAccount  string
+	CreditAccount string
+}
+
+// BreakType is a break item type (e.g. amount) resolved by a resolution (e.g. write_off).
+type BreakType struct {
+	Resolution domain.Resolution
+	ItemType   domain.ItemType
+}
+
+// ChartOfAccounts maps a break type to accounts, e.g. a write-off and a fee difference of an amount
+// are posted to different accounts.
+type ChartOfAccounts map[BreakType]AccountMapping
+
+func NewJournalEntryID() uuid.UUID {
+	return uuid.Must(uuid.NewV7())
+}
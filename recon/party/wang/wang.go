@@ -2,6 +2,7 @@ package wang
 
 import (
 	"context"
+	"slices"
 	"time"
 
 	"github.com/shopspring/decimal"
@@ -12,10 +13,14 @@ import (
 )
 
 const (
-	StatusCompleted string = "completed"
-	StatusDeclined  string = "declined"
+	StatusCompleted  string = "completed"
+	StatusDeclined   string = "declined"
+	StatusProcessing string = "processing"
 )
 
+// non-terminal statuses
+var pendingStatuses = []string{StatusProcessing}
+
 type Transaction struct {
 	ID                    string          `json:"id"`
 	CreatedAt             time.Time       `json:"created_at"`
@@ -51,7 +56,15 @@ func (t *Transaction) GetTimestamp() time.Time {
 	return t.CreatedAt
 }
 
-var _ domain.Transaction = (*Transaction)(nil)
+// IsPending returns true for a non-terminal status, an unknown status is a break.
+func (t *Transaction) IsPending() bool {
+	return slices.Contains(pendingStatuses, t.Status)
+}
+
+var (
+	_ domain.Transaction        = (*Transaction)(nil)
+	_ domain.PendingTransaction = (*Transaction)(nil)
+)
 
 type GetTransfersReqeust struct {
 	Party2ID string `json:"party2_id"`
@@ -2,6 +2,7 @@ package zhang
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 
 	"github.com/shopspring/decimal"
@@ -65,14 +66,15 @@ func (cpr *Comparator) Compare(
 	return reconItems, nil
 }
 
-func (cpr *Comparator) cmpStatus(partyValue1, partyValue2 string) bool {
+// cmpStatus returns whether the statuses match, and whether the status of party1 has a mapping
+func (cpr *Comparator) cmpStatus(partyValue1, partyValue2 string) (bool, bool) {
 	switch partyValue1 {
 	case wang.StatusCompleted:
-		return partyValue2 == StatusCompleted
+		return partyValue2 == StatusCompleted, true
 	case wang.StatusDeclined:
-		return partyValue2 == StatusFailed
+		return partyValue2 == StatusFailed, true
 	default:
-		return false
+		return false, false
 	}
 }
 
@@ -92,21 +94,33 @@ func (cpr *Comparator) compareStatus(
 		partyValue2 = &temp
 	}
 
-	var matched bool
-
-	if partyTransaction1 == nil || partyTransaction2 == nil {
-		matched = false
-	} else {
-		matched = cpr.cmpStatus(*partyValue1, *partyValue2)
-	}
-
-	return &domain.TxReconItem{
+	reconItem := &domain.TxReconItem{
 		Type:        string(domain.ItemTypeStatus),
 		Key:         string(reconItemKeyStatus),
 		PartyValue1: partyValue1,
 		PartyValue2: partyValue2,
-		Matched:     matched,
 	}
+
+	if partyTransaction1 == nil || partyTransaction2 == nil {
+		setMissingCounterpart(reconItem)
+
+		return reconItem
+	}
+
+	matched, mapped := cpr.cmpStatus(*partyValue1, *partyValue2)
+
+	reconItem.Matched = matched
+
+	switch {
+	case !mapped:
+		reconItem.ReasonCode = domain.ReasonCodeStatusMappingNotFound
+		reconItem.Reason = fmt.Sprintf("status mapping not found for '%s'", *partyValue1)
+	case !matched:
+		reconItem.ReasonCode = domain.ReasonCodeValueMismatch
+		reconItem.Reason = fmt.Sprintf("status '%s' does not match '%s'", *partyValue1, *partyValue2)
+	}
+
+	return reconItem
 }
 
 func (cpr *Comparator) compareCurrency(
@@ -125,20 +139,27 @@ func (cpr *Comparator) compareCurrency(
 		partyValue2 = &temp
 	}
 
-	var matched bool
-	if partyTransaction1 == nil || partyTransaction2 == nil {
-		matched = false
-	} else {
-		matched = *partyValue1 == *partyValue2
-	}
-
-	return &domain.TxReconItem{
+	reconItem := &domain.TxReconItem{
 		Type:        string(domain.ItemTypeCurrency),
 		Key:         string(reconItemKeyCurrency),
 		PartyValue1: partyValue1,
 		PartyValue2: partyValue2,
-		Matched:     matched,
 	}
+
+	if partyTransaction1 == nil || partyTransaction2 == nil {
+		setMissingCounterpart(reconItem)
+
+		return reconItem
+	}
+
+	reconItem.Matched = *partyValue1 == *partyValue2
+
+	if !reconItem.Matched {
+		reconItem.ReasonCode = domain.ReasonCodeValueMismatch
+		reconItem.Reason = fmt.Sprintf("currency '%s' does not match '%s'", *partyValue1, *partyValue2)
+	}
+
+	return reconItem
 }
 
 func (cpr *Comparator) compareAmount(
@@ -147,7 +168,9 @@ func (cpr *Comparator) compareAmount(
 ) *domain.TxReconItem {
 	var partyValue1, partyValue2 *string
 
-	var partyAmount1, partyAmount2, difference *decimal.Decimal
+	var partyAmount1, partyAmount2 *decimal.Decimal
+
+	var parseErr error
 
 	if partyTransaction1 != nil {
 		temp1 := partyTransaction1.ReceivingAmount
@@ -161,30 +184,56 @@ func (cpr *Comparator) compareAmount(
 		amount2, err := decimal.NewFromString(partyTransaction2.LocalAmount)
 		if err != nil {
 			cpr.Logger.Warn("failed to parse payout amount from partyTransaction2", slog.Any("error", err))
-		}
 
-		partyAmount2 = &amount2
+			// keep the raw value, so that it is visible in the result
+			temp := partyTransaction2.LocalAmount
+			partyValue2 = &temp
 
-		temp := partyAmount2.String()
-		partyValue2 = &temp
-	}
-
-	var matched bool
-	if partyAmount1 == nil || partyAmount2 == nil {
-		matched = false
-	} else {
-		temp := partyAmount2.Sub(*partyAmount1)
-		difference = &temp
+			parseErr = err
+		} else {
+			partyAmount2 = &amount2
 
-		matched = partyAmount1.Cmp(*partyAmount2) == 0
+			temp := partyAmount2.String()
+			partyValue2 = &temp
+		}
 	}
 
-	return &domain.TxReconItem{
+	reconItem := &domain.TxReconItem{
 		Type:        string(domain.ItemTypeAmount),
 		Key:         string(reconItemKeyAmount),
 		PartyValue1: partyValue1,
 		PartyValue2: partyValue2,
-		Matched:     matched,
-		Difference:  difference,
+	}
+
+	switch {
+	case partyTransaction1 == nil || partyTransaction2 == nil:
+		setMissingCounterpart(reconItem)
+	case parseErr != nil:
+		reconItem.ReasonCode = domain.ReasonCodeParseFailed
+		reconItem.Reason = fmt.Sprintf("amount '%s' of party2 could not be parsed: %v", *partyValue2, parseErr)
+	default:
+		difference := partyAmount2.Sub(*partyAmount1)
+
+		reconItem.Difference = &difference
+		reconItem.Matched = partyAmount1.Cmp(*partyAmount2) == 0
+
+		if !reconItem.Matched {
+			reconItem.ReasonCode = domain.ReasonCodeValueMismatch
+			reconItem.Reason = fmt.Sprintf("amount %s does not match %s, difference is %s",
+				*partyValue1, *partyValue2, difference.String())
+		}
+	}
+
+	return reconItem
+}
+
+func setMissingCounterpart(reconItem *domain.TxReconItem) {
+	reconItem.Matched = false
+	reconItem.ReasonCode = domain.ReasonCodeMissingCounterpart
+
+	if reconItem.PartyValue1 == nil {
+		reconItem.Reason = "transaction not found at party1"
+	} else {
+		reconItem.Reason = "transaction not found at party2"
 	}
 }
@@ -0,0 +1,114 @@
+package zhang
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/shopspring/decimal"
+
+	"github.com/ivxivx/go-recon/recon/domain"
+	"github.com/ivxivx/go-recon/recon/party/wang"
+)
+
+func Test_Comparator(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name         string
+		transaction1 *wang.Transaction
+		transaction2 *Transaction
+		items        []*domain.TxReconItem
+	}{
+		{
+			name:         "status mapping not found",
+			transaction1: &wang.Transaction{Status: wang.StatusProcessing, ReceivingAmount: decimal.RequireFromString("500"), ReceivingCurrency: "COP"},
+			transaction2: &Transaction{Status: StatusCompleted, LocalAmount: "500", LocalCurrency: "COP"},
+			items: []*domain.TxReconItem{
+				{
+					Type: string(domain.ItemTypeStatus), Key: string(reconItemKeyStatus), PartyValue1: ptr("processing"), PartyValue2: ptr("Completed"),
+					ReasonCode: domain.ReasonCodeStatusMappingNotFound, Reason: "status mapping not found for 'processing'",
+				},
+				{Type: string(domain.ItemTypeCurrency), Key: string(reconItemKeyCurrency), PartyValue1: ptr("COP"), PartyValue2: ptr("COP"), Matched: true},
+				{Type: string(domain.ItemTypeAmount), Key: string(reconItemKeyAmount), PartyValue1: ptr("500"), PartyValue2: ptr("500"), Matched: true, Difference: ptr(decimal.Zero)},
+			},
+		},
+		{
+			name:         "status mismatched",
+			transaction1: &wang.Transaction{Status: wang.StatusDeclined, ReceivingAmount: decimal.RequireFromString("500"), ReceivingCurrency: "COP"},
+			transaction2: &Transaction{Status: StatusCompleted, LocalAmount: "500", LocalCurrency: "USD"},
+			items: []*domain.TxReconItem{
+				{
+					Type: string(domain.ItemTypeStatus), Key: string(reconItemKeyStatus), PartyValue1: ptr("declined"), PartyValue2: ptr("Completed"),
+					ReasonCode: domain.ReasonCodeValueMismatch, Reason: "status 'declined' does not match 'Completed'",
+				},
+				{
+					Type: string(domain.ItemTypeCurrency), Key: string(reconItemKeyCurrency), PartyValue1: ptr("COP"), PartyValue2: ptr("USD"),
+					ReasonCode: domain.ReasonCodeValueMismatch, Reason: "currency 'COP' does not match 'USD'",
+				},
+				{Type: string(domain.ItemTypeAmount), Key: string(reconItemKeyAmount), PartyValue1: ptr("500"), PartyValue2: ptr("500"), Matched: true, Difference: ptr(decimal.Zero)},
+			},
+		},
+		{
+			name:         "amount parse failed",
+			transaction1: &wang.Transaction{Status: wang.StatusCompleted, ReceivingAmount: decimal.RequireFromString("500"), ReceivingCurrency: "COP"},
+			transaction2: &Transaction{Status: StatusCompleted, LocalAmount: "5OO", LocalCurrency: "COP"},
+			items: []*domain.TxReconItem{
+				{Type: string(domain.ItemTypeStatus), Key: string(reconItemKeyStatus), PartyValue1: ptr("completed"), PartyValue2: ptr("Completed"), Matched: true},
+				{Type: string(domain.ItemTypeCurrency), Key: string(reconItemKeyCurrency), PartyValue1: ptr("COP"), PartyValue2: ptr("COP"), Matched: true},
+				{
+					Type: string(domain.ItemTypeAmount), Key: string(reconItemKeyAmount), PartyValue1: ptr("500"), PartyValue2: ptr("5OO"),
+					ReasonCode: domain.ReasonCodeParseFailed, Reason: "amount '5OO' of party2 could not be parsed: can't convert 5OO to decimal",
+				},
+			},
+		},
+		{
+			name:         "missing counterpart",
+			transaction2: &Transaction{Status: StatusCompleted, LocalAmount: "500", LocalCurrency: "COP"},
+			items: []*domain.TxReconItem{
+				{
+					Type: string(domain.ItemTypeStatus), Key: string(reconItemKeyStatus), PartyValue2: ptr("Completed"),
+					ReasonCode: domain.ReasonCodeMissingCounterpart, Reason: "transaction not found at party1",
+				},
+				{
+					Type: string(domain.ItemTypeCurrency), Key: string(reconItemKeyCurrency), PartyValue2: ptr("COP"),
+					ReasonCode: domain.ReasonCodeMissingCounterpart, Reason: "transaction not found at party1",
+				},
+				{
+					Type: string(domain.ItemTypeAmount), Key: string(reconItemKeyAmount), PartyValue2: ptr("500"),
+					ReasonCode: domain.ReasonCodeMissingCounterpart, Reason: "transaction not found at party1",
+				},
+			},
+		},
+	}
+
+	comparator := &Comparator{
+		Logger: slog.Default(),
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var transaction1, transaction2 domain.Transaction
+
+			if tc.transaction1 != nil {
+				transaction1 = tc.transaction1
+			}
+
+			if tc.transaction2 != nil {
+				transaction2 = tc.transaction2
+			}
+
+			items, err := comparator.Compare(context.Background(), transaction1, transaction2)
+			if err != nil {
+				t.Fatalf("failed to compare: %v", err)
+			}
+
+			if diff := cmp.Diff(tc.items, items); diff != "" {
+				t.Fatalf("unexpected items (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"github.com/ivxivx/go-recon/recon/party"
 	"github.com/ivxivx/go-recon/recon/party/wang"
 	"github.com/ivxivx/go-recon/recon/transaction"
+	"github.com/ivxivx/go-recon/recon/transaction/balance"
 	"github.com/ivxivx/go-recon/recon/transaction/collection"
 )
 
@@ -63,6 +64,36 @@ func Test_Recon(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "amount mismatched",
+			transactions: []*wang.Transaction{
+				{
+					ID:                "3bd0a9ee-c4ee-402f-8f39-f80642455838",
+					CreatedAt:         time.Date(2024, 5, 31, 13, 45, 22, 0, time.UTC),
+					Status:            wang.StatusCompleted,
+					ReceivingAmount:   decimal.RequireFromString("400.00"),
+					ReceivingCurrency: "COP",
+				},
+			},
+			txReconResult: &domain.TxReconResult{
+				MatchingKey:          "3bd0a9ee-c4ee-402f-8f39-f80642455838",
+				ResultType:           recon.ResultAmountMismatched,
+				TransactionTimestamp: time.Date(2024, 5, 31, 13, 45, 22, 0, time.UTC),
+				TransactionType:      "payout",
+				PartyID1:             string(party.Wang),
+				PartyID2:             string(party.Zhang),
+				PartyTransactionID1:  ptr("3bd0a9ee-c4ee-402f-8f39-f80642455838"),
+				PartyTransactionID2:  ptr("403020377"),
+				Items: []*domain.TxReconItem{
+					{Type: string(domain.ItemTypeStatus), Key: string(reconItemKeyStatus), PartyValue1: ptr("completed"), PartyValue2: ptr("Completed"), Matched: true},
+					{Type: string(domain.ItemTypeCurrency), Key: string(reconItemKeyCurrency), PartyValue1: ptr("COP"), PartyValue2: ptr("COP"), Matched: true},
+					{
+						Type: string(domain.ItemTypeAmount), Key: string(reconItemKeyAmount), PartyValue1: ptr("400"), PartyValue2: ptr("500"), Matched: false, Difference: ptr(decimal.RequireFromString("100")),
+						ReasonCode: domain.ReasonCodeValueMismatch, Reason: "amount 400 does not match 500, difference is 100",
+					},
+				},
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -171,6 +202,76 @@ func Test_Recon(t *testing.T) {
 	}
 }
 
+func Test_Recon_Balance(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		res, err := json.Marshal([]*wang.Transaction{
+			{
+				ID:                "3bd0a9ee-c4ee-402f-8f39-f80642455838",
+				CreatedAt:         time.Date(2024, 5, 31, 13, 45, 22, 0, time.UTC),
+				Status:            wang.StatusCompleted,
+				ReceivingAmount:   decimal.RequireFromString("500.00"),
+				ReceivingCurrency: "COP",
+			},
+		})
+		if err != nil {
+			t.Errorf("failed to marshal response: %v", err)
+
+			return
+		}
+
+		_, _ = w.Write(res)
+	}))
+
+	defer server.Close()
+
+	reader1 := reader.NewJSONReader(slog.Default(), rs.NewHTTPResource(slog.Default(), server.URL+"/transactions"), &wang.RecordExtractor{})
+
+	timeTransformer := &transformer.TimeTransformer{
+		InputFormat:  time.DateTime,
+		OutputFormat: time.RFC3339,
+	}
+
+	reader2 := reader.NewCsvReader(
+		slog.Default(),
+		rs.NewLocalResource(slog.Default(), "./testdata/Report_20240801.csv"),
+	).WithTransformers(
+		map[string]transformer.FieldTransformer{
+			"CREATION_DATE": timeTransformer,
+		},
+	)
+
+	balanceReader := reader.NewCsvReader(slog.Default(), rs.NewLocalResource(slog.Default(), "./testdata/Balance_20240801.csv"))
+
+	reconciler := transaction.NewReconciler[*wang.Transaction, *Transaction](
+		slog.Default(),
+		string(party.Wang),
+		string(party.Zhang),
+		collection.NewInMemoryCollection[*wang.Transaction](reader1),
+		collection.NewInMemoryCollection[*Transaction](reader2),
+		&Comparator{Logger: slog.Default()},
+	).WithParty2BalanceChecker(balance.NewStatementBalanceChecker[*Balance](slog.Default(), string(party.Zhang), balanceReader))
+
+	reconResult, err := reconciler.Process(ctx)
+	if err != nil {
+		t.Fatalf("failed to reconcile: %v", err)
+	}
+
+	if len(reconResult.Balances) != 1 {
+		t.Fatalf("expected 1 balance result, got %d", len(reconResult.Balances))
+	}
+
+	// the canceled payout is not paid from the account
+	result := reconResult.Balances[0]
+
+	if result.ResultType != recon.ResultBalanceMatched || result.MovementCount != 1 || !result.Movements.Equal(decimal.RequireFromString("-500")) {
+		t.Fatalf("unexpected balance result %s, movements %s of %d", result.ResultType, result.Movements, result.MovementCount)
+	}
+}
+
 func ptr[T any](s T) *T {
 	return &s
 }
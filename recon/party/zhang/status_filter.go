@@ -11,6 +11,8 @@ type StatusFilter struct {
 	validStatuses []string
 }
 
+// NewStatusFilter passes terminal statuses only, add StatusProcessing to the valid statuses
+// when pending transactions are handled by a pending store.
 func NewStatusFilter() *StatusFilter {
 	filter := &StatusFilter{}
 
@@ -1,20 +1,30 @@
 package zhang
 
 import (
+	"slices"
 	"time"
 
+	"github.com/shopspring/decimal"
+
 	"github.com/ivxivx/go-recon/recon/domain"
 )
 
 const (
-	StatusCompleted string = "Completed"
-	StatusFailed    string = "Canceled"
+	StatusCompleted  string = "Completed"
+	StatusFailed     string = "Canceled"
+	StatusProcessing string = "Processing"
+
+	// PayoutAccount is the prefunded account which payouts are paid from, there is one per local currency
+	PayoutAccount string = "payout"
 
 	reconItemKeyStatus   domain.ItemKey = "status"
 	reconItemKeyCurrency domain.ItemKey = "currency"
 	reconItemKeyAmount   domain.ItemKey = "amount"
 )
 
+// non-terminal statuses
+var pendingStatuses = []string{StatusProcessing}
+
 type Transaction struct {
 	CreationDate          time.Time `csv:"CREATION_DATE"`
 	ExternalTransactionID string    `csv:"EXTERNAL_TRANSACTION_ID"`
@@ -46,4 +56,62 @@ func (t *Transaction) GetTimestamp() time.Time {
 	return t.CreationDate
 }
 
-var _ domain.Transaction = (*Transaction)(nil)
+// IsPending returns true for a non-terminal status, an unknown status is a break.
+func (t *Transaction) IsPending() bool {
+	return slices.Contains(pendingStatuses, t.Status)
+}
+
+func (t *Transaction) GetAccount() string {
+	return PayoutAccount
+}
+
+func (t *Transaction) GetCurrency() string {
+	return t.LocalCurrency
+}
+
+// GetAmount returns the payout as a debit, an unparsable amount is reported by the comparator and does not move the balance.
+func (t *Transaction) GetAmount() decimal.Decimal {
+	amount, err := decimal.NewFromString(t.LocalAmount)
+	if err != nil {
+		return decimal.Zero
+	}
+
+	return amount.Neg()
+}
+
+// IsSettled returns true for a completed payout, a canceled or processing one is not paid from the account.
+func (t *Transaction) IsSettled() bool {
+	return t.Status == StatusCompleted
+}
+
+var (
+	_ domain.Transaction        = (*Transaction)(nil)
+	_ domain.PendingTransaction = (*Transaction)(nil)
+	_ domain.Movement           = (*Transaction)(nil)
+)
+
+// Balance is a balance record of the statement of an account.
+type Balance struct {
+	Account        string          `csv:"ACCOUNT"`
+	Currency       string          `csv:"CURRENCY"`
+	OpeningBalance decimal.Decimal `csv:"OPENING_BALANCE"`
+	ClosingBalance decimal.Decimal `csv:"CLOSING_BALANCE"`
+}
+
+func (b *Balance) GetAccount() string {
+	return b.Account
+}
+
+func (b *Balance) GetCurrency() string {
+	return b.Currency
+}
+
+func (b *Balance) GetOpeningBalance() decimal.Decimal {
+	return b.OpeningBalance
+}
+
+func (b *Balance) GetClosingBalance() decimal.Decimal {
+	return b.ClosingBalance
+}
+
+var _ domain.Balance = (*Balance)(nil)
@@ -1,8 +1,55 @@
 package recon
 
+// result types of transaction reconciliation, this is a closed set
 const (
 	ResultMatched    string = "matched"
-	ResultMismatched string = "mismatched"
+	ResultMismatched string = "mismatched" // multiple or unknown types of breaks
 	ResultParty1Only string = "party1_only"
 	ResultParty2Only string = "party2_only"
+	// transaction is in a non-terminal state and is re-evaluated in later runs until the SLA is exceeded
+	ResultPending string = "pending"
+
+	// single type of break, values are the same as the item types
+	ResultStatusMismatched   string = "status"
+	ResultAmountMismatched   string = "amount"
+	ResultCurrencyMismatched string = "currency"
+
+	// run-level results of balance reconciliation (opening + movements = closing)
+	ResultBalanceMatched    string = "balance_matched"
+	ResultBalanceMismatched string = "balance_mismatched"
+	ResultBalanceMissing    string = "balance_missing"
 )
+
+// ResultTypes returns all result types of transaction reconciliation.
+func ResultTypes() []string {
+	return []string{
+		ResultMatched,
+		ResultMismatched,
+		ResultStatusMismatched,
+		ResultAmountMismatched,
+		ResultCurrencyMismatched,
+		ResultParty1Only,
+		ResultParty2Only,
+		ResultPending,
+	}
+}
+
+// IsBothPartiesResultType returns whether a transaction found at both parties can have the result type.
+func IsBothPartiesResultType(resultType string) bool {
+	switch resultType {
+	case ResultMatched, ResultMismatched, ResultStatusMismatched, ResultAmountMismatched, ResultCurrencyMismatched:
+		return true
+	default:
+		return false
+	}
+}
+
+func IsResultType(resultType string) bool {
+	for _, rt := range ResultTypes() {
+		if rt == resultType {
+			return true
+		}
+	}
+
+	return false
+}
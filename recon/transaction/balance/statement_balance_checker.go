@@ -0,0 +1,213 @@
+package balance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/ivxivx/go-recon/batch"
+	"github.com/ivxivx/go-recon/recon"
+	"github.com/ivxivx/go-recon/recon/domain"
+	txn "github.com/ivxivx/go-recon/recon/transaction"
+)
+
+type balanceKey struct {
+	account  string
+	currency string
+}
+
+type movementSum struct {
+	amount decimal.Decimal
+	count  int
+}
+
+// StatementBalanceChecker verifies the balances of a statement against the movements of reconciled transactions.
+// The movements are reset when it is opened, so that it can be reused by several runs.
+type StatementBalanceChecker[B domain.Balance] struct {
+	logger  *slog.Logger
+	partyID string
+	reader  batch.Reader
+
+	movements map[balanceKey]*movementSum
+
+	now func() time.Time
+}
+
+func NewStatementBalanceChecker[B domain.Balance](
+	logger *slog.Logger,
+	partyID string,
+	reader batch.Reader,
+) *StatementBalanceChecker[B] {
+	return &StatementBalanceChecker[B]{
+		logger:    logger,
+		partyID:   partyID,
+		reader:    reader,
+		movements: make(map[balanceKey]*movementSum),
+		now:       time.Now,
+	}
+}
+
+var (
+	_ txn.BalanceChecker = (*StatementBalanceChecker[domain.Balance])(nil)
+	_ batch.OpenCloser   = (*StatementBalanceChecker[domain.Balance])(nil)
+)
+
+// Open starts a run, it forgets the movements of a previous run.
+func (c *StatementBalanceChecker[B]) Open(_ context.Context) error {
+	c.movements = make(map[balanceKey]*movementSum)
+
+	return nil
+}
+
+func (c *StatementBalanceChecker[B]) Close(_ context.Context) error {
+	return nil
+}
+
+// Add counts the movement of a settled transaction, other transactions do not change the balance.
+func (c *StatementBalanceChecker[B]) Add(_ context.Context, transaction domain.Transaction) error {
+	movement, cok := transaction.(domain.Movement)
+	if !cok {
+		return &recon.UnexpectedTypeError{FromType: transaction, ToType: (*domain.Movement)(nil)}
+	}
+
+	if !movement.IsSettled() {
+		return nil
+	}
+
+	key := balanceKey{account: movement.GetAccount(), currency: movement.GetCurrency()}
+
+	sum, found := c.movements[key]
+	if !found {
+		sum = &movementSum{}
+		c.movements[key] = sum
+	}
+
+	sum.amount = sum.amount.Add(movement.GetAmount())
+	sum.count++
+
+	return nil
+}
+
+func (c *StatementBalanceChecker[B]) Check(ctx context.Context) ([]*domain.BalanceReconResult, error) {
+	balances, err := c.readBalances(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*domain.BalanceReconResult, 0, len(balances)+len(c.movements))
+
+	now := c.now()
+
+	for _, balance := range balances {
+		key := balanceKey{account: balance.GetAccount(), currency: balance.GetCurrency()}
+
+		sum, found := c.movements[key]
+		if !found {
+			sum = &movementSum{}
+		}
+
+		opening := balance.GetOpeningBalance()
+		closing := balance.GetClosingBalance()
+		difference := closing.Sub(opening.Add(sum.amount))
+
+		resultType := recon.ResultBalanceMatched
+		if !difference.IsZero() {
+			resultType = recon.ResultBalanceMismatched
+		}
+
+		results = append(results, &domain.BalanceReconResult{
+			ID:             domain.NewBalanceReconResultID(),
+			CreatedAt:      now,
+			UpdatedAt:      now,
+			PartyID:        c.partyID,
+			Account:        key.account,
+			Currency:       key.currency,
+			ResultType:     resultType,
+			OpeningBalance: &opening,
+			ClosingBalance: &closing,
+			Movements:      sum.amount,
+			MovementCount:  sum.count,
+			Difference:     &difference,
+		})
+	}
+
+	// movements without any balance record cannot be proved
+	for key, sum := range c.movements {
+		if _, found := balances[key]; found {
+			continue
+		}
+
+		results = append(results, &domain.BalanceReconResult{
+			ID:            domain.NewBalanceReconResultID(),
+			CreatedAt:     now,
+			UpdatedAt:     now,
+			PartyID:       c.partyID,
+			Account:       key.account,
+			Currency:      key.currency,
+			ResultType:    recon.ResultBalanceMissing,
+			Movements:     sum.amount,
+			MovementCount: sum.count,
+		})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Account == results[j].Account {
+			return results[i].Currency < results[j].Currency
+		}
+
+		return results[i].Account < results[j].Account
+	})
+
+	return results, nil
+}
+
+func (c *StatementBalanceChecker[B]) readBalances(ctx context.Context) (map[balanceKey]B, error) {
+	err := c.reader.Open(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if errC := c.reader.Close(ctx); errC != nil {
+			c.logger.Warn("failed to close balance reader", slog.Any("error", errC))
+		}
+	}()
+
+	balances := make(map[balanceKey]B)
+
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("context cancelled: %w", ctx.Err())
+		default:
+			var balance B
+
+			err := c.reader.Read(ctx, &balance)
+			if err != nil {
+				if errors.Is(err, io.EOF) {
+					break loop
+				}
+
+				return nil, err
+			}
+
+			key := balanceKey{account: balance.GetAccount(), currency: balance.GetCurrency()}
+
+			if _, found := balances[key]; found {
+				c.logger.Warn("duplicate balance record, keep the last one",
+					slog.String("account", key.account), slog.String("currency", key.currency))
+			}
+
+			balances[key] = balance
+		}
+	}
+
+	return balances, nil
+}
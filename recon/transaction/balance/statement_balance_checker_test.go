@@ -0,0 +1,211 @@
+package balance
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/ivxivx/go-recon/recon"
+	"github.com/ivxivx/go-recon/recon/domain"
+)
+
+type testBalance struct {
+	account string
+	opening string
+	closing string
+}
+
+func (b *testBalance) GetAccount() string  { return b.account }
+func (b *testBalance) GetCurrency() string { return "USD" }
+
+func (b *testBalance) GetOpeningBalance() decimal.Decimal {
+	return decimal.RequireFromString(b.opening)
+}
+
+func (b *testBalance) GetClosingBalance() decimal.Decimal {
+	return decimal.RequireFromString(b.closing)
+}
+
+type testMovement struct {
+	id        string
+	account   string
+	amount    string
+	unsettled bool
+}
+
+func (m *testMovement) GetMatchingKey() string     { return m.id }
+func (m *testMovement) GetID() string              { return m.id }
+func (m *testMovement) GetExternalID() *string     { return nil }
+func (m *testMovement) GetType() string            { return "payout" }
+func (m *testMovement) GetTimestamp() time.Time    { return time.Time{} }
+func (m *testMovement) GetAccount() string         { return m.account }
+func (m *testMovement) GetCurrency() string        { return "USD" }
+func (m *testMovement) GetAmount() decimal.Decimal { return decimal.RequireFromString(m.amount) }
+func (m *testMovement) IsSettled() bool            { return !m.unsettled }
+
+// testBalanceReader reads the balances again each time it is opened.
+type testBalanceReader struct {
+	balances []*testBalance
+	index    int
+}
+
+func (r *testBalanceReader) Open(_ context.Context) error {
+	r.index = 0
+
+	return nil
+}
+
+func (r *testBalanceReader) Close(_ context.Context) error {
+	return nil
+}
+
+func (r *testBalanceReader) Read(_ context.Context, record any) error {
+	if r.index >= len(r.balances) {
+		return io.EOF
+	}
+
+	*record.(**testBalance) = r.balances[r.index]
+	r.index++
+
+	return nil
+}
+
+func Test_StatementBalanceChecker(t *testing.T) {
+	t.Parallel()
+
+	createdAt := time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name      string
+		balances  []*testBalance
+		movements []*testMovement
+		results   []*domain.BalanceReconResult
+	}{
+		{
+			name:     "matched",
+			balances: []*testBalance{{account: "A1", opening: "100.00", closing: "70.00"}},
+			movements: []*testMovement{
+				{id: "1", account: "A1", amount: "-50.00"},
+				{id: "2", account: "A1", amount: "20.00"},
+			},
+			results: []*domain.BalanceReconResult{
+				{
+					CreatedAt: createdAt, UpdatedAt: createdAt, PartyID: "party", Account: "A1", Currency: "USD",
+					ResultType: recon.ResultBalanceMatched, OpeningBalance: ptr("100.00"), ClosingBalance: ptr("70.00"),
+					Movements: decimal.RequireFromString("-30.00"), MovementCount: 2, Difference: ptr("0"),
+				},
+			},
+		},
+		{
+			name:      "mismatched",
+			balances:  []*testBalance{{account: "A1", opening: "100.00", closing: "60.00"}},
+			movements: []*testMovement{{id: "1", account: "A1", amount: "-30.00"}},
+			results: []*domain.BalanceReconResult{
+				{
+					CreatedAt: createdAt, UpdatedAt: createdAt, PartyID: "party", Account: "A1", Currency: "USD",
+					ResultType: recon.ResultBalanceMismatched, OpeningBalance: ptr("100.00"), ClosingBalance: ptr("60.00"),
+					Movements: decimal.RequireFromString("-30.00"), MovementCount: 1, Difference: ptr("-10.00"),
+				},
+			},
+		},
+		{
+			name:     "unsettled movements",
+			balances: []*testBalance{{account: "A1", opening: "100.00", closing: "50.00"}},
+			movements: []*testMovement{
+				{id: "1", account: "A1", amount: "-50.00"},
+				{id: "2", account: "A1", amount: "-20.00", unsettled: true},
+			},
+			results: []*domain.BalanceReconResult{
+				{
+					CreatedAt: createdAt, UpdatedAt: createdAt, PartyID: "party", Account: "A1", Currency: "USD",
+					ResultType: recon.ResultBalanceMatched, OpeningBalance: ptr("100.00"), ClosingBalance: ptr("50.00"),
+					Movements: decimal.RequireFromString("-50.00"), MovementCount: 1, Difference: ptr("0"),
+				},
+			},
+		},
+		{
+			name:     "balance without movements",
+			balances: []*testBalance{{account: "A1", opening: "100.00", closing: "100.00"}},
+			results: []*domain.BalanceReconResult{
+				{
+					CreatedAt: createdAt, UpdatedAt: createdAt, PartyID: "party", Account: "A1", Currency: "USD",
+					ResultType: recon.ResultBalanceMatched, OpeningBalance: ptr("100.00"), ClosingBalance: ptr("100.00"),
+					Movements: decimal.Zero, Difference: ptr("0"),
+				},
+			},
+		},
+		{
+			name:      "movements without balance",
+			balances:  []*testBalance{{account: "A1", opening: "100.00", closing: "100.00"}},
+			movements: []*testMovement{{id: "1", account: "A2", amount: "10.00"}},
+			results: []*domain.BalanceReconResult{
+				{
+					CreatedAt: createdAt, UpdatedAt: createdAt, PartyID: "party", Account: "A1", Currency: "USD",
+					ResultType: recon.ResultBalanceMatched, OpeningBalance: ptr("100.00"), ClosingBalance: ptr("100.00"),
+					Movements: decimal.Zero, Difference: ptr("0"),
+				},
+				{
+					CreatedAt: createdAt, UpdatedAt: createdAt, PartyID: "party", Account: "A2", Currency: "USD",
+					ResultType: recon.ResultBalanceMissing, Movements: decimal.RequireFromString("10.00"), MovementCount: 1,
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+
+			checker := NewStatementBalanceChecker[*testBalance](slog.Default(), "party", &testBalanceReader{balances: tc.balances})
+			checker.now = func() time.Time { return createdAt }
+
+			// a reused checker counts the movements of the last run only
+			for run := 0; run < 2; run++ {
+				if err := checker.Open(ctx); err != nil {
+					t.Fatalf("failed to open checker: %v", err)
+				}
+
+				for _, movement := range tc.movements {
+					if err := checker.Add(ctx, movement); err != nil {
+						t.Fatalf("failed to add movement: %v", err)
+					}
+				}
+
+				results, err := checker.Check(ctx)
+				if err != nil {
+					t.Fatalf("failed to check balances: %v", err)
+				}
+
+				if err := checker.Close(ctx); err != nil {
+					t.Fatalf("failed to close checker: %v", err)
+				}
+
+				for _, result := range results {
+					if result.ID == uuid.Nil {
+						t.Fatalf("expected result ID of %s", result.Account)
+					}
+
+					result.ID = uuid.Nil
+				}
+
+				if diff := cmp.Diff(tc.results, results); diff != "" {
+					t.Fatalf("unexpected results of run %d (-want +got):\n%s", run, diff)
+				}
+			}
+		})
+	}
+}
+
+func ptr(s string) *decimal.Decimal {
+	d := decimal.RequireFromString(s)
+
+	return &d
+}
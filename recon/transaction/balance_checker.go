@@ -0,0 +1,14 @@
+package transaction
+
+import (
+	"context"
+
+	"github.com/ivxivx/go-recon/recon/domain"
+)
+
+type BalanceChecker interface {
+	// Add adds the movement of a reconciled transaction
+	Add(ctx context.Context, transaction domain.Transaction) error
+	// Check verifies opening balance + movements = closing balance, per account and currency
+	Check(ctx context.Context) ([]*domain.BalanceReconResult, error)
+}
@@ -0,0 +1,133 @@
+package transaction
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ivxivx/go-recon/recon/domain"
+)
+
+const (
+	// party2 collection is iterated first, then party1 collection
+	phaseParty2 = 0
+	phaseParty1 = 1
+	phaseDone   = 2
+)
+
+// Checkpoint is the progress of a run, a restarted run with the same run ID resumes from it.
+// A saved checkpoint holds what changed since the previous one, a loaded checkpoint holds all of it.
+type Checkpoint struct {
+	RunID     string    `json:"run_id"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// 0: party2 collection is iterated, 1: party1 collection is iterated, 2: both are done
+	Phase int `json:"phase"`
+	// number of records read from the collection being iterated
+	Position int `json:"position"`
+	// party ID -> fingerprint of the collection, a run resumes only with the same inputs
+	Fingerprints map[string]string `json:"fingerprints,omitempty"`
+
+	BothParties map[string]*domain.TxReconResult `json:"both_parties"`
+	Party1Only  map[string]*domain.TxReconResult `json:"party1_only"`
+	Party2Only  map[string]*domain.TxReconResult `json:"party2_only"`
+	Errors      []*CheckpointError               `json:"errors,omitempty"`
+	// matching key -> time when it was first seen pending, nil if it is resolved
+	Pending map[string]*time.Time `json:"pending,omitempty"`
+	// trace target -> trace, always in full since there are few targets
+	Traces map[string]*Trace `json:"traces,omitempty"`
+}
+
+// Merge applies a checkpoint saved after c.
+func (c *Checkpoint) Merge(next *Checkpoint) {
+	c.RunID = next.RunID
+	c.UpdatedAt = next.UpdatedAt
+	c.Phase = next.Phase
+	c.Position = next.Position
+
+	if len(next.Fingerprints) > 0 {
+		c.Fingerprints = next.Fingerprints
+	}
+
+	c.BothParties = mergeResults(c.BothParties, next.BothParties)
+	c.Party1Only = mergeResults(c.Party1Only, next.Party1Only)
+	c.Party2Only = mergeResults(c.Party2Only, next.Party2Only)
+	c.Errors = append(c.Errors, next.Errors...)
+
+	for matchingKey, since := range next.Pending {
+		if c.Pending == nil {
+			c.Pending = make(map[string]*time.Time)
+		}
+
+		c.Pending[matchingKey] = since
+	}
+
+	if len(next.Traces) > 0 {
+		c.Traces = next.Traces
+	}
+}
+
+func mergeResults(results, next map[string]*domain.TxReconResult) map[string]*domain.TxReconResult {
+	if results == nil {
+		results = make(map[string]*domain.TxReconResult, len(next))
+	}
+
+	for key, result := range next {
+		results[key] = result
+	}
+
+	return results
+}
+
+// CheckpointError is a RecordError without the record, so that it can be persisted.
+type CheckpointError struct {
+	PartyID       string `json:"party_id"`
+	TransactionID string `json:"transaction_id,omitempty"`
+	MatchingKey   string `json:"matching_key,omitempty"`
+	Message       string `json:"message"`
+}
+
+// CheckpointStore keeps the checkpoints of a run. Save adds a checkpoint to those saved before, so that
+// a save costs what changed only, and Load returns them merged.
+type CheckpointStore interface {
+	Load(ctx context.Context, runID string) (*Checkpoint, bool, error)
+	Save(ctx context.Context, checkpoint *Checkpoint) error
+	Delete(ctx context.Context, runID string) error
+}
+
+// Fingerprinter is implemented by collections which can tell whether their records changed, e.g. by a hash
+// of the records. A checkpoint is only resumed if the fingerprints of the collections are the same.
+type Fingerprinter interface {
+	Fingerprint() string
+}
+
+// checkpointDelta tracks what changed since the last checkpoint.
+type checkpointDelta struct {
+	bothParties map[string]struct{}
+	party1Only  map[string]struct{}
+	party2Only  map[string]struct{}
+	errors      int // number of errors saved
+	pending     map[string]*time.Time
+}
+
+func newCheckpointDelta() *checkpointDelta {
+	return &checkpointDelta{
+		bothParties: make(map[string]struct{}),
+		party1Only:  make(map[string]struct{}),
+		party2Only:  make(map[string]struct{}),
+		pending:     make(map[string]*time.Time),
+	}
+}
+
+type CheckpointMismatchError struct {
+	RunID   string
+	PartyID string
+}
+
+func (e *CheckpointMismatchError) Error() string {
+	return fmt.Sprintf("input of %s differs from the checkpoint of run %s", e.PartyID, e.RunID)
+}
+
+// ResultSink receives every result once it is reconciled.
+type ResultSink interface {
+	Emit(ctx context.Context, result *domain.TxReconResult) error
+}
@@ -0,0 +1,165 @@
+package checkpoint
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/goccy/go-json"
+
+	"github.com/ivxivx/go-recon/batch"
+	"github.com/ivxivx/go-recon/recon/transaction"
+)
+
+const (
+	defaultFileMode = 0o644
+	fileExtension   = ".jsonl"
+)
+
+// FileCheckpointStore keeps the checkpoints of a run ID as JSON lines in a local directory. Save appends a
+// line, Load merges the lines. A line torn by a crash is dropped, so the run resumes from the line before.
+type FileCheckpointStore struct {
+	logger *slog.Logger
+	dir    string
+}
+
+func NewFileCheckpointStore(logger *slog.Logger, dir string) *FileCheckpointStore {
+	return &FileCheckpointStore{
+		logger: logger,
+		dir:    dir,
+	}
+}
+
+var _ transaction.CheckpointStore = (*FileCheckpointStore)(nil)
+
+func (s *FileCheckpointStore) Load(_ context.Context, runID string) (*transaction.Checkpoint, bool, error) {
+	filePath, err := s.getFilePath(runID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	file, err := os.OpenFile(filePath, os.O_RDWR, defaultFileMode)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, false, nil
+		}
+
+		return nil, false, &batch.IoError{Operation: batch.IoOpen, Resource: filePath, Err: err}
+	}
+	defer file.Close()
+
+	var checkpoint *transaction.Checkpoint
+
+	var offset int64
+
+	reader := bufio.NewReader(file)
+
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, false, &batch.IoError{Operation: batch.IoRead, Resource: filePath, Err: err}
+		}
+
+		// a line without newline was not completely written
+		if !bytes.HasSuffix(line, []byte("\n")) {
+			break
+		}
+
+		next := &transaction.Checkpoint{}
+
+		if json.Unmarshal(line, next) != nil {
+			break
+		}
+
+		if checkpoint == nil {
+			checkpoint = next
+		} else {
+			checkpoint.Merge(next)
+		}
+
+		offset += int64(len(line))
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, false, &batch.IoError{Operation: batch.IoRead, Resource: filePath, Err: err}
+	}
+
+	if info.Size() > offset {
+		s.logger.Warn("dropping torn checkpoint",
+			slog.String("file", filePath), slog.Int64("offset", offset), slog.Int64("size", info.Size()))
+
+		// the next save appends after the last complete line
+		err = file.Truncate(offset)
+		if err != nil {
+			return nil, false, &batch.IoError{Operation: batch.IoWrite, Resource: filePath, Err: err}
+		}
+	}
+
+	if checkpoint == nil {
+		return nil, false, nil
+	}
+
+	return checkpoint, true, nil
+}
+
+func (s *FileCheckpointStore) Save(_ context.Context, checkpoint *transaction.Checkpoint) error {
+	filePath, err := s.getFilePath(checkpoint.RunID)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		return &batch.IoError{Operation: batch.IoWrite, Resource: filePath, Err: err}
+	}
+
+	file, err := os.OpenFile(filePath, os.O_WRONLY|os.O_APPEND|os.O_CREATE, defaultFileMode)
+	if err != nil {
+		return &batch.IoError{Operation: batch.IoOpen, Resource: filePath, Err: err}
+	}
+
+	_, err = file.Write(append(data, '\n'))
+	if err == nil {
+		err = file.Sync()
+	}
+
+	closeErr := file.Close()
+	if err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return &batch.IoError{Operation: batch.IoWrite, Resource: filePath, Err: err}
+	}
+
+	return nil
+}
+
+func (s *FileCheckpointStore) Delete(_ context.Context, runID string) error {
+	filePath, err := s.getFilePath(runID)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(filePath)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return &batch.IoError{Operation: batch.IoClose, Resource: filePath, Err: err}
+	}
+
+	return nil
+}
+
+func (s *FileCheckpointStore) getFilePath(runID string) (string, error) {
+	if runID == "" || runID != filepath.Base(runID) || runID == "." || runID == ".." {
+		return "", &batch.IllegalArgumentError{Name: "runID", Value: runID}
+	}
+
+	return filepath.Join(s.dir, runID+fileExtension), nil
+}
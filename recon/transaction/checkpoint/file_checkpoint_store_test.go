@@ -0,0 +1,69 @@
+package checkpoint
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ivxivx/go-recon/recon/domain"
+	"github.com/ivxivx/go-recon/recon/transaction"
+)
+
+func Test_FileCheckpointStore(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	dir := t.TempDir()
+	store := NewFileCheckpointStore(slog.Default(), dir)
+
+	for _, checkpoint := range []*transaction.Checkpoint{
+		{RunID: "run1", Position: 2, BothParties: map[string]*domain.TxReconResult{"k1": {MatchingKey: "k1"}}},
+		{RunID: "run1", Position: 4, BothParties: map[string]*domain.TxReconResult{"k2": {MatchingKey: "k2"}}},
+	} {
+		err := store.Save(ctx, checkpoint)
+		if err != nil {
+			t.Fatalf("failed to save checkpoint: %v", err)
+		}
+	}
+
+	// a save interrupted by a crash
+	file, err := os.OpenFile(filepath.Join(dir, "run1.jsonl"), os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+
+	_, _ = file.WriteString(`{"run_id":"run1","position":6,"both_par`)
+	_ = file.Close()
+
+	checkpoint, found, err := store.Load(ctx, "run1")
+	if err != nil || !found {
+		t.Fatalf("failed to load checkpoint: found=%v err=%v", found, err)
+	}
+
+	if checkpoint.Position != 4 || len(checkpoint.BothParties) != 2 {
+		t.Fatalf("expected merged checkpoint at position 4, got %+v", checkpoint)
+	}
+
+	// the next save follows the last complete line
+	err = store.Save(ctx, &transaction.Checkpoint{RunID: "run1", Position: 6})
+	if err != nil {
+		t.Fatalf("failed to save checkpoint: %v", err)
+	}
+
+	checkpoint, _, err = store.Load(ctx, "run1")
+	if err != nil || checkpoint.Position != 6 || len(checkpoint.BothParties) != 2 {
+		t.Fatalf("expected merged checkpoint at position 6, got %+v, %v", checkpoint, err)
+	}
+
+	err = store.Delete(ctx, "run1")
+	if err != nil {
+		t.Fatalf("failed to delete checkpoint: %v", err)
+	}
+
+	_, found, err = store.Load(ctx, "run1")
+	if err != nil || found {
+		t.Fatalf("expected no checkpoint, got found=%v err=%v", found, err)
+	}
+}
@@ -0,0 +1,89 @@
+package classifier
+
+import (
+	"context"
+	"maps"
+	"slices"
+
+	"github.com/ivxivx/go-recon/batch"
+	"github.com/ivxivx/go-recon/recon"
+	"github.com/ivxivx/go-recon/recon/domain"
+	txn "github.com/ivxivx/go-recon/recon/transaction"
+)
+
+// PrecedenceClassifier classifies a transaction by its failed items.
+//
+// If all failed items map to the same result type, it is the result type. Otherwise the result type with the
+// highest precedence is returned, or recon.ResultMismatched if none of them has a precedence.
+type PrecedenceClassifier struct {
+	// item type -> result type
+	itemResultTypes map[string]string
+	// result types, the first one has the highest precedence
+	precedence []string
+}
+
+// NewPrecedenceClassifier fails if a result type of the precedence is not one of a transaction found at both parties,
+// or is listed twice.
+func NewPrecedenceClassifier(precedence ...string) (*PrecedenceClassifier, error) {
+	seen := make(map[string]bool, len(precedence))
+
+	for _, resultType := range precedence {
+		if !recon.IsBothPartiesResultType(resultType) || seen[resultType] {
+			return nil, &batch.IllegalArgumentError{Name: "precedence", Value: resultType}
+		}
+
+		seen[resultType] = true
+	}
+
+	return &PrecedenceClassifier{
+		itemResultTypes: txn.DefaultItemResultTypes(),
+		precedence:      slices.Clone(precedence),
+	}, nil
+}
+
+// WithItemResultTypes overrides the result types of item types, a result type which is not one of
+// a transaction found at both parties is a recon.ResultMismatched break.
+func (c *PrecedenceClassifier) WithItemResultTypes(itemResultTypes map[string]string) *PrecedenceClassifier {
+	if itemResultTypes != nil {
+		c.itemResultTypes = maps.Clone(itemResultTypes)
+	}
+
+	return c
+}
+
+var _ txn.ResultClassifier = (*PrecedenceClassifier)(nil)
+
+func (c *PrecedenceClassifier) Classify(_ context.Context, items []*domain.TxReconItem) (string, error) {
+	resultTypes := make(map[string]bool)
+
+	for _, item := range items {
+		if item.Matched {
+			continue
+		}
+
+		resultType, found := c.itemResultTypes[item.Type]
+		if !found || !recon.IsBothPartiesResultType(resultType) {
+			resultType = recon.ResultMismatched
+		}
+
+		resultTypes[resultType] = true
+	}
+
+	if len(resultTypes) == 0 {
+		return recon.ResultMatched, nil
+	}
+
+	for _, resultType := range c.precedence {
+		if resultTypes[resultType] {
+			return resultType, nil
+		}
+	}
+
+	if len(resultTypes) == 1 {
+		for resultType := range resultTypes {
+			return resultType, nil
+		}
+	}
+
+	return recon.ResultMismatched, nil
+}
@@ -0,0 +1,125 @@
+package classifier
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ivxivx/go-recon/batch"
+	"github.com/ivxivx/go-recon/recon"
+	"github.com/ivxivx/go-recon/recon/domain"
+	txn "github.com/ivxivx/go-recon/recon/transaction"
+)
+
+func Test_PrecedenceClassifier(t *testing.T) {
+	t.Parallel()
+
+	status := &domain.TxReconItem{Type: string(domain.ItemTypeStatus)}
+	amount := &domain.TxReconItem{Type: string(domain.ItemTypeAmount)}
+	currency := &domain.TxReconItem{Type: string(domain.ItemTypeCurrency)}
+	fee := &domain.TxReconItem{Type: "fee"}
+	matched := &domain.TxReconItem{Type: string(domain.ItemTypeAmount), Matched: true}
+
+	testCases := []struct {
+		name            string
+		precedence      []string
+		itemResultTypes map[string]string
+		items           []*domain.TxReconItem
+		expected        string
+	}{
+		{
+			name:     "all matched",
+			items:    []*domain.TxReconItem{matched},
+			expected: recon.ResultMatched,
+		},
+		{
+			name:     "single type",
+			items:    []*domain.TxReconItem{amount, matched},
+			expected: recon.ResultAmountMismatched,
+		},
+		{
+			name:       "precedence",
+			precedence: []string{recon.ResultStatusMismatched, recon.ResultAmountMismatched},
+			items:      []*domain.TxReconItem{amount, status, currency},
+			expected:   recon.ResultStatusMismatched,
+		},
+		{
+			name:     "multiple types without precedence",
+			items:    []*domain.TxReconItem{amount, currency},
+			expected: recon.ResultMismatched,
+		},
+		{
+			name:     "unknown item type",
+			items:    []*domain.TxReconItem{fee},
+			expected: recon.ResultMismatched,
+		},
+		{
+			name:            "custom item type",
+			itemResultTypes: map[string]string{"fee": recon.ResultAmountMismatched},
+			items:           []*domain.TxReconItem{fee},
+			expected:        recon.ResultAmountMismatched,
+		},
+		{
+			name:            "item type of a single party",
+			itemResultTypes: map[string]string{"fee": recon.ResultParty1Only},
+			items:           []*domain.TxReconItem{fee},
+			expected:        recon.ResultMismatched,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			classifier, err := NewPrecedenceClassifier(tc.precedence...)
+			if err != nil {
+				t.Fatalf("failed to create classifier: %v", err)
+			}
+
+			resultType, err := classifier.WithItemResultTypes(tc.itemResultTypes).Classify(context.Background(), tc.items)
+			if err != nil {
+				t.Fatalf("failed to classify: %v", err)
+			}
+
+			if resultType != tc.expected {
+				t.Fatalf("expected %s, got %s", tc.expected, resultType)
+			}
+		})
+	}
+}
+
+func Test_PrecedenceClassifier_InvalidPrecedence(t *testing.T) {
+	t.Parallel()
+
+	for _, precedence := range [][]string{
+		{recon.ResultPending},
+		{recon.ResultParty1Only},
+		{"fee"},
+		{recon.ResultAmountMismatched, recon.ResultAmountMismatched},
+	} {
+		_, err := NewPrecedenceClassifier(precedence...)
+		if batch.CodeOf(err) != batch.CodeIllegalArgument {
+			t.Fatalf("expected illegal argument for %v, got %v", precedence, err)
+		}
+	}
+}
+
+func Test_PrecedenceClassifier_ItemResultTypesCopied(t *testing.T) {
+	t.Parallel()
+
+	itemResultTypes := txn.DefaultItemResultTypes()
+	itemResultTypes[string(domain.ItemTypeAmount)] = recon.ResultStatusMismatched
+
+	classifier, err := NewPrecedenceClassifier()
+	if err != nil {
+		t.Fatalf("failed to create classifier: %v", err)
+	}
+
+	resultType, err := classifier.Classify(context.Background(), []*domain.TxReconItem{{Type: string(domain.ItemTypeAmount)}})
+	if err != nil {
+		t.Fatalf("failed to classify: %v", err)
+	}
+
+	if resultType != recon.ResultAmountMismatched {
+		t.Fatalf("expected defaults unchanged, got %s", resultType)
+	}
+}
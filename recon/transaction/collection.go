@@ -11,3 +11,14 @@ type Collection interface {
 	batch.Reader
 	Find(ctx context.Context, matchingKey string) (domain.Transaction, bool)
 }
+
+// ErrorCollector is implemented by collections which skip malformed records and collect their errors.
+// The run fails on them with ErrorPolicyFailFast, they are collected with ErrorPolicyContinue.
+type ErrorCollector interface {
+	GetErrors() []error
+}
+
+// Counter is implemented by collections which know the number of records.
+type Counter interface {
+	Count() int
+}
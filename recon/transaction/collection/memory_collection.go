@@ -2,11 +2,15 @@ package collection
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
 	"reflect"
 
+	"github.com/goccy/go-json"
+
 	"github.com/ivxivx/go-recon/batch"
 	"github.com/ivxivx/go-recon/recon/domain"
 	"github.com/ivxivx/go-recon/recon/transaction"
@@ -16,12 +20,16 @@ const (
 	maxCount = 1000
 )
 
+// InMemoryCollection loads all records on Open, a record which cannot be read fails Open unless read errors are skipped.
 type InMemoryCollection[T domain.Transaction] struct {
-	reader batch.Reader
-
-	items   []T
-	index   int
-	itemMap map[string]T
+	reader         batch.Reader
+	skipReadErrors int
+
+	items       []T
+	index       int
+	itemMap     map[string]T
+	errors      []error
+	fingerprint string
 }
 
 func NewInMemoryCollection[T domain.Transaction](
@@ -32,7 +40,22 @@ func NewInMemoryCollection[T domain.Transaction](
 	}
 }
 
-var _ transaction.Collection = (*InMemoryCollection[domain.Transaction])(nil)
+// WithSkipReadErrors skips a record which cannot be read, its error is available via GetErrors, so that
+// the reconciler decides by its error policy whether the run fails. Open fails once more than limit consecutive
+// records cannot be read, as the reader may not be able to move forward.
+func (col *InMemoryCollection[T]) WithSkipReadErrors(limit int) *InMemoryCollection[T] {
+	col.skipReadErrors = limit
+
+	return col
+}
+
+var (
+	_ transaction.Collection     = (*InMemoryCollection[domain.Transaction])(nil)
+	_ transaction.ErrorCollector = (*InMemoryCollection[domain.Transaction])(nil)
+	_ transaction.Counter        = (*InMemoryCollection[domain.Transaction])(nil)
+	_ transaction.Fingerprinter  = (*InMemoryCollection[domain.Transaction])(nil)
+	_ batch.ProgressReporter     = (*InMemoryCollection[domain.Transaction])(nil)
+)
 
 func (col *InMemoryCollection[T]) Open(ctx context.Context) error {
 	err := col.reader.Open(ctx)
@@ -43,6 +66,10 @@ func (col *InMemoryCollection[T]) Open(ctx context.Context) error {
 	items := make([]T, 0, maxCount)
 	itemMap := make(map[string]T, maxCount)
 
+	var readErrors []error
+
+	var consecutiveErrors int
+
 loop:
 	for {
 		select {
@@ -57,16 +84,33 @@ loop:
 					break loop
 				}
 
-				return err
+				consecutiveErrors++
+
+				if consecutiveErrors > col.skipReadErrors {
+					if col.skipReadErrors > 0 {
+						return fmt.Errorf("too many consecutive read errors: %w", err)
+					}
+
+					return err
+				}
+
+				readErrors = append(readErrors, err)
+
+				continue
 			}
 
+			consecutiveErrors = 0
+
 			items = append(items, item)
 			itemMap[item.GetMatchingKey()] = item
 		}
 	}
 
 	col.items = items
+	col.index = 0
 	col.itemMap = itemMap
+	col.errors = readErrors
+	col.fingerprint = ""
 
 	return nil
 }
@@ -102,3 +146,48 @@ func (col *InMemoryCollection[T]) Find(_ context.Context, matchingKey string) (d
 
 	return item, true
 }
+
+func (col *InMemoryCollection[T]) GetErrors() []error {
+	return col.errors
+}
+
+// Fingerprint is a hash of the records and read errors of Open, it is computed once asked for, i.e. by a
+// reconciler with a checkpoint store.
+func (col *InMemoryCollection[T]) Fingerprint() string {
+	if col.fingerprint != "" {
+		return col.fingerprint
+	}
+
+	hash := sha256.New()
+
+	for _, item := range col.items {
+		data, err := json.Marshal(item)
+		if err != nil {
+			// a record which cannot be marshaled is hashed by its printed form
+			data = []byte(fmt.Sprintf("%+v", item))
+		}
+
+		_, _ = hash.Write(data)
+	}
+
+	for _, err := range col.errors {
+		_, _ = hash.Write([]byte(err.Error()))
+	}
+
+	col.fingerprint = "sha256:" + hex.EncodeToString(hash.Sum(nil))
+
+	return col.fingerprint
+}
+
+func (col *InMemoryCollection[T]) Count() int {
+	return len(col.items)
+}
+
+// GetProgress returns the progress of loading the collection.
+func (col *InMemoryCollection[T]) GetProgress() batch.Progress {
+	if reporter, cok := col.reader.(batch.ProgressReporter); cok {
+		return reporter.GetProgress()
+	}
+
+	return batch.Progress{TotalBytes: -1}
+}
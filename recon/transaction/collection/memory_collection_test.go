@@ -0,0 +1,158 @@
+package collection
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+type testRecord struct {
+	ID string
+}
+
+func (r *testRecord) GetMatchingKey() string  { return r.ID }
+func (r *testRecord) GetID() string           { return r.ID }
+func (r *testRecord) GetExternalID() *string  { return nil }
+func (r *testRecord) GetType() string         { return "payout" }
+func (r *testRecord) GetTimestamp() time.Time { return time.Time{} }
+
+// testReader reads the records, a nil record is a malformed row.
+type testReader struct {
+	records []*testRecord
+	index   int
+}
+
+func (r *testReader) Open(_ context.Context) error {
+	r.index = 0
+
+	return nil
+}
+
+func (r *testReader) Close(_ context.Context) error {
+	return nil
+}
+
+func (r *testReader) Read(_ context.Context, record any) error {
+	if r.index >= len(r.records) {
+		return io.EOF
+	}
+
+	r.index++
+
+	if r.records[r.index-1] == nil {
+		return errors.New("malformed row")
+	}
+
+	*record.(**testRecord) = r.records[r.index-1]
+
+	return nil
+}
+
+func Test_InMemoryCollection_Open(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name           string
+		records        []*testRecord
+		skipReadErrors int
+		count          int
+		errors         int
+		failed         bool
+	}{
+		{
+			name:    "malformed row fails",
+			records: []*testRecord{{ID: "1"}, nil, {ID: "2"}},
+			failed:  true,
+		},
+		{
+			name:           "malformed rows skipped",
+			records:        []*testRecord{{ID: "1"}, nil, {ID: "2"}, nil},
+			skipReadErrors: 1,
+			count:          2,
+			errors:         2,
+		},
+		{
+			name:           "too many consecutive malformed rows",
+			records:        []*testRecord{{ID: "1"}, nil, nil, nil},
+			skipReadErrors: 2,
+			failed:         true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+
+			col := NewInMemoryCollection[*testRecord](&testReader{records: tc.records}).WithSkipReadErrors(tc.skipReadErrors)
+
+			err := col.Open(ctx)
+			if tc.failed {
+				if err == nil {
+					t.Fatalf("expected error")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("failed to open collection: %v", err)
+			}
+
+			if col.Count() != tc.count || len(col.GetErrors()) != tc.errors {
+				t.Fatalf("expected %d records and %d errors, got %d and %v", tc.count, tc.errors, col.Count(), col.GetErrors())
+			}
+
+			// a reopened collection is read from the start
+			for run := 0; run < 2; run++ {
+				var record *testRecord
+
+				if err := col.Read(ctx, &record); err != nil || record.ID != "1" {
+					t.Fatalf("unexpected first record %v of run %d, error: %v", record, run, err)
+				}
+
+				if err := col.Open(ctx); err != nil {
+					t.Fatalf("failed to reopen collection: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func Test_InMemoryCollection_Fingerprint(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	reader := &testReader{records: []*testRecord{{ID: "1"}, {ID: "2"}}}
+
+	col := NewInMemoryCollection[*testRecord](reader)
+
+	if err := col.Open(ctx); err != nil {
+		t.Fatalf("failed to open collection: %v", err)
+	}
+
+	fingerprint := col.Fingerprint()
+
+	if err := col.Open(ctx); err != nil {
+		t.Fatalf("failed to reopen collection: %v", err)
+	}
+
+	if col.Fingerprint() != fingerprint {
+		t.Fatalf("expected the same fingerprint of the same records")
+	}
+
+	// a reopened collection is hashed again
+	reader.records = []*testRecord{{ID: "1"}, {ID: "3"}}
+
+	if err := col.Open(ctx); err != nil {
+		t.Fatalf("failed to reopen collection: %v", err)
+	}
+
+	if col.Fingerprint() == fingerprint {
+		t.Fatalf("expected another fingerprint of changed records")
+	}
+}
@@ -22,6 +22,9 @@ var _ txn.Filter = (*AllPassFilter)(nil)
 func (f *AllPassFilter) Filter(ctx context.Context, transaction domain.Transaction) (bool, error) {
 	for _, filter := range f.filters {
 		pass, err := filter.Filter(ctx, transaction)
+
+		txn.TraceFilter(ctx, filter, pass, err)
+
 		if err != nil {
 			return false, err
 		}
@@ -0,0 +1,52 @@
+package transaction
+
+import (
+	"context"
+	"time"
+
+	"github.com/ivxivx/go-recon/recon/domain"
+)
+
+type RunStartEvent struct {
+	RunID     string
+	Party1ID  string
+	Party2ID  string
+	StartedAt time.Time
+}
+
+type RunEndEvent struct {
+	RunID     string
+	StartedAt time.Time
+	EndedAt   time.Time
+	Count     ReconResultCount // empty if the run failed
+	Err       error
+}
+
+type CollectionEvent struct {
+	PartyID string
+	// number of records in the collection when opened, or processed when closed, -1 if unknown
+	RecordCount int
+}
+
+// Listener is notified of the lifecycle of a run, e.g. to report progress, collect metrics or trigger
+// downstream jobs. Listeners are called synchronously, so they must not block.
+type Listener interface {
+	OnRunStart(ctx context.Context, event *RunStartEvent)
+	OnRunEnd(ctx context.Context, event *RunEndEvent)
+	OnCollectionOpened(ctx context.Context, event *CollectionEvent)
+	OnCollectionClosed(ctx context.Context, event *CollectionEvent)
+	OnResult(ctx context.Context, result *domain.TxReconResult)
+	OnError(ctx context.Context, err error)
+}
+
+// NopListener does nothing, it can be embedded to implement only some of the hooks.
+type NopListener struct{}
+
+var _ Listener = NopListener{}
+
+func (NopListener) OnRunStart(context.Context, *RunStartEvent)           {}
+func (NopListener) OnRunEnd(context.Context, *RunEndEvent)               {}
+func (NopListener) OnCollectionOpened(context.Context, *CollectionEvent) {}
+func (NopListener) OnCollectionClosed(context.Context, *CollectionEvent) {}
+func (NopListener) OnResult(context.Context, *domain.TxReconResult)      {}
+func (NopListener) OnError(context.Context, error)                       {}
@@ -0,0 +1,167 @@
+package pending
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/goccy/go-json"
+
+	"github.com/ivxivx/go-recon/batch"
+	"github.com/ivxivx/go-recon/recon/transaction"
+)
+
+const (
+	defaultFileMode = 0o644
+)
+
+// FilePendingStore keeps pending matching keys in a local JSON file, which is loaded on Open
+// and saved on every change, so that a crash does not lose the state.
+type FilePendingStore struct {
+	logger    *slog.Logger
+	filePath  string
+	retention time.Duration
+
+	mu    sync.RWMutex
+	items map[string]time.Time
+
+	now func() time.Time
+}
+
+func NewFilePendingStore(logger *slog.Logger, filePath string) *FilePendingStore {
+	return &FilePendingStore{
+		logger:   logger,
+		filePath: filePath,
+		items:    make(map[string]time.Time),
+		now:      time.Now,
+	}
+}
+
+// WithRetention prunes keys pending for longer than retention on Open, e.g. keys of transactions
+// which are no longer reconciled, thus never resolved. It should be much longer than the SLA.
+func (s *FilePendingStore) WithRetention(retention time.Duration) *FilePendingStore {
+	s.retention = retention
+
+	return s
+}
+
+var (
+	_ transaction.PendingStore = (*FilePendingStore)(nil)
+	_ batch.OpenCloser         = (*FilePendingStore)(nil)
+)
+
+func (s *FilePendingStore) Open(_ context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := os.ReadFile(s.filePath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			s.logger.Info("pending store does not exist, start empty", slog.String("resource", s.filePath))
+
+			return nil
+		}
+
+		return &batch.IoError{Operation: batch.IoOpen, Resource: s.filePath, Err: err}
+	}
+
+	items := make(map[string]time.Time)
+
+	err = json.Unmarshal(data, &items)
+	if err != nil {
+		return &batch.IoError{Operation: batch.IoRead, Resource: s.filePath, Err: err}
+	}
+
+	s.items = items
+
+	if s.retention <= 0 {
+		return nil
+	}
+
+	pruned := 0
+
+	for matchingKey, since := range items {
+		if s.now().Sub(since) > s.retention {
+			delete(items, matchingKey)
+
+			pruned++
+		}
+	}
+
+	if pruned == 0 {
+		return nil
+	}
+
+	s.logger.Info("stale pending keys pruned", slog.String("resource", s.filePath), slog.Int("count", pruned))
+
+	return s.save()
+}
+
+// Close does nothing, the store is saved on every change.
+func (s *FilePendingStore) Close(_ context.Context) error {
+	return nil
+}
+
+// save writes the items to the file, the caller must hold the lock.
+func (s *FilePendingStore) save() error {
+	data, err := json.Marshal(s.items)
+	if err != nil {
+		return &batch.IoError{Operation: batch.IoWrite, Resource: s.filePath, Err: err}
+	}
+
+	// write to a temporary file first, so that a crash does not corrupt the store
+	tempFile := filepath.Join(filepath.Dir(s.filePath), "."+filepath.Base(s.filePath)+".tmp")
+
+	err = os.WriteFile(tempFile, data, defaultFileMode)
+	if err != nil {
+		return &batch.IoError{Operation: batch.IoWrite, Resource: tempFile, Err: err}
+	}
+
+	err = os.Rename(tempFile, s.filePath)
+	if err != nil {
+		return &batch.IoError{Operation: batch.IoClose, Resource: s.filePath, Err: err}
+	}
+
+	return nil
+}
+
+func (s *FilePendingStore) Get(_ context.Context, matchingKey string) (time.Time, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	since, found := s.items[matchingKey]
+
+	return since, found, nil
+}
+
+func (s *FilePendingStore) Put(_ context.Context, matchingKey string, since time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if current, found := s.items[matchingKey]; found && current.Equal(since) {
+		return nil
+	}
+
+	s.items[matchingKey] = since
+
+	return s.save()
+}
+
+// Delete forgets a resolved matching key.
+func (s *FilePendingStore) Delete(_ context.Context, matchingKey string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, found := s.items[matchingKey]; !found {
+		return nil
+	}
+
+	delete(s.items, matchingKey)
+
+	return s.save()
+}
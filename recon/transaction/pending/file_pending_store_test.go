@@ -0,0 +1,84 @@
+package pending
+
+import (
+	"context"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func Test_FilePendingStore(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	now := time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC)
+	filePath := filepath.Join(t.TempDir(), "pending.json")
+
+	store := NewFilePendingStore(slog.Default(), filePath)
+
+	if err := store.Open(ctx); err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+
+	for matchingKey, since := range map[string]time.Time{
+		"k1": now.Add(-time.Hour),
+		"k2": now.Add(-48 * time.Hour),
+		"k3": now.Add(-time.Hour),
+	} {
+		if err := store.Put(ctx, matchingKey, since); err != nil {
+			t.Fatalf("failed to put %s: %v", matchingKey, err)
+		}
+	}
+
+	if err := store.Delete(ctx, "k3"); err != nil {
+		t.Fatalf("failed to delete: %v", err)
+	}
+
+	// the store is not closed, as if the process crashed
+	reopened := NewFilePendingStore(slog.Default(), filePath).WithRetention(24 * time.Hour)
+	reopened.now = func() time.Time { return now }
+
+	if err := reopened.Open(ctx); err != nil {
+		t.Fatalf("failed to reopen store: %v", err)
+	}
+
+	defer reopened.Close(ctx)
+
+	testCases := []struct {
+		matchingKey string
+		found       bool
+	}{
+		{matchingKey: "k1", found: true},
+		// pending for longer than the retention
+		{matchingKey: "k2", found: false},
+		// resolved
+		{matchingKey: "k3", found: false},
+	}
+
+	for _, tc := range testCases {
+		since, found, err := reopened.Get(ctx, tc.matchingKey)
+		if err != nil {
+			t.Fatalf("failed to get %s: %v", tc.matchingKey, err)
+		}
+
+		if found != tc.found {
+			t.Fatalf("expected %s found=%t, got %t", tc.matchingKey, tc.found, found)
+		}
+
+		if found && !since.Equal(now.Add(-time.Hour)) {
+			t.Fatalf("unexpected since %v of %s", since, tc.matchingKey)
+		}
+	}
+
+	// the pruned key is removed from the file too
+	store = NewFilePendingStore(slog.Default(), filePath)
+
+	if err := store.Open(ctx); err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+
+	if _, found, _ := store.Get(ctx, "k2"); found {
+		t.Fatalf("expected k2 pruned from file")
+	}
+}
@@ -0,0 +1,49 @@
+package pending
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ivxivx/go-recon/recon/transaction"
+)
+
+type MemoryPendingStore struct {
+	mu    sync.RWMutex
+	items map[string]time.Time
+}
+
+func NewMemoryPendingStore() *MemoryPendingStore {
+	return &MemoryPendingStore{
+		items: make(map[string]time.Time),
+	}
+}
+
+var _ transaction.PendingStore = (*MemoryPendingStore)(nil)
+
+func (s *MemoryPendingStore) Get(_ context.Context, matchingKey string) (time.Time, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	since, found := s.items[matchingKey]
+
+	return since, found, nil
+}
+
+func (s *MemoryPendingStore) Put(_ context.Context, matchingKey string, since time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.items[matchingKey] = since
+
+	return nil
+}
+
+func (s *MemoryPendingStore) Delete(_ context.Context, matchingKey string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.items, matchingKey)
+
+	return nil
+}
@@ -0,0 +1,14 @@
+package transaction
+
+import (
+	"context"
+	"time"
+)
+
+// PendingStore remembers matching keys of pending transactions across runs.
+type PendingStore interface {
+	// Get returns the time when the matching key was first seen pending
+	Get(ctx context.Context, matchingKey string) (time.Time, bool, error)
+	Put(ctx context.Context, matchingKey string, since time.Time) error
+	Delete(ctx context.Context, matchingKey string) error
+}
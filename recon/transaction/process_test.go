@@ -0,0 +1,527 @@
+package transaction_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/ivxivx/go-recon/recon/domain"
+	"github.com/ivxivx/go-recon/recon/transaction"
+	"github.com/ivxivx/go-recon/recon/transaction/checkpoint"
+	"github.com/ivxivx/go-recon/recon/transaction/collection"
+	"github.com/ivxivx/go-recon/recon/transaction/filter"
+)
+
+type testRecord struct {
+	ID     string
+	Key    string
+	Amount string
+}
+
+func (r *testRecord) GetMatchingKey() string  { return r.Key }
+func (r *testRecord) GetID() string           { return r.ID }
+func (r *testRecord) GetExternalID() *string  { return nil }
+func (r *testRecord) GetType() string         { return "payout" }
+func (r *testRecord) GetTimestamp() time.Time { return time.Time{} }
+
+// testReader reads the records, a nil record is a malformed row.
+type testReader struct {
+	records  []*testRecord
+	index    int
+	closeErr error
+}
+
+func (r *testReader) Open(_ context.Context) error {
+	r.index = 0
+
+	return nil
+}
+
+func (r *testReader) Close(_ context.Context) error {
+	return r.closeErr
+}
+
+func (r *testReader) Read(_ context.Context, record any) error {
+	if r.index >= len(r.records) {
+		return io.EOF
+	}
+
+	r.index++
+
+	if r.records[r.index-1] == nil {
+		return errors.New("malformed row")
+	}
+
+	reflect.ValueOf(record).Elem().Set(reflect.ValueOf(r.records[r.index-1]))
+
+	return nil
+}
+
+// testComparator compares the amounts, it fails for an amount which is not a number.
+type testComparator struct{}
+
+func (testComparator) Compare(_ context.Context, transaction1, transaction2 domain.Transaction) ([]*domain.TxReconItem, error) {
+	for _, transaction := range []domain.Transaction{transaction1, transaction2} {
+		if transaction != nil && transaction.(*testRecord).Amount == "NaN" {
+			return nil, errors.New("amount is not a number")
+		}
+	}
+
+	item := &domain.TxReconItem{Type: string(domain.ItemTypeAmount), Key: "amount"}
+
+	if transaction1 != nil {
+		item.PartyValue1 = &transaction1.(*testRecord).Amount
+	}
+
+	if transaction2 != nil {
+		item.PartyValue2 = &transaction2.(*testRecord).Amount
+	}
+
+	item.Matched = item.PartyValue1 != nil && item.PartyValue2 != nil && *item.PartyValue1 == *item.PartyValue2
+
+	return []*domain.TxReconItem{item}, nil
+}
+
+// newTestCollection skips malformed rows, so that the error policy of the reconciler applies.
+func newTestCollection(records ...*testRecord) *collection.InMemoryCollection[*testRecord] {
+	return collection.NewInMemoryCollection[*testRecord](&testReader{records: records}).
+		WithSkipReadErrors(transaction.MaxConsecutiveReadErrors)
+}
+
+func newTestReconciler(col1, col2 transaction.Collection) *transaction.Reconciler[*testRecord, *testRecord] {
+	return transaction.NewReconciler[*testRecord, *testRecord](slog.Default(), "party1", "party2", col1, col2, testComparator{})
+}
+
+// testFilter drops the given matching keys, and fails for the matching keys with an error.
+type testFilter struct {
+	drop  map[string]bool
+	fail  map[string]bool
+	calls map[string]int
+}
+
+func (f *testFilter) Filter(_ context.Context, transaction domain.Transaction) (bool, error) {
+	f.calls[transaction.GetMatchingKey()]++
+
+	if f.fail[transaction.GetMatchingKey()] {
+		return false, errors.New("bad timestamp")
+	}
+
+	return !f.drop[transaction.GetMatchingKey()], nil
+}
+
+func Test_Reconciler_Trace(t *testing.T) {
+	t.Parallel()
+
+	subFilter := &testFilter{
+		drop:  map[string]bool{"k2": true},
+		fail:  map[string]bool{"k3": true},
+		calls: map[string]int{},
+	}
+
+	rc := newTestReconciler(
+		newTestCollection(&testRecord{ID: "a1", Key: "k1", Amount: "10"}, &testRecord{ID: "a2", Key: "k2", Amount: "20"}, &testRecord{ID: "a3", Key: "k3", Amount: "30"}),
+		newTestCollection(&testRecord{ID: "b1", Key: "k1", Amount: "10"}, &testRecord{ID: "b4", Key: "k4", Amount: "40"}),
+	).
+		WithFilter(filter.NewAllPassFilter(subFilter)).
+		WithErrorPolicy(transaction.ErrorPolicyContinue).
+		WithTrace("b1", "k2", "k3")
+
+	reconResult, err := rc.Process(context.Background())
+	if err != nil {
+		t.Fatalf("failed to reconcile: %v", err)
+	}
+
+	// each record is filtered once per pass, tracing does not evaluate the filters again
+	for matchingKey, calls := range map[string]int{"k1": 2, "k2": 1, "k3": 1, "k4": 1} {
+		if subFilter.calls[matchingKey] != calls {
+			t.Fatalf("expected %d filter calls of %s, got %d", calls, matchingKey, subFilter.calls[matchingKey])
+		}
+	}
+
+	if len(reconResult.Traces) != 3 {
+		t.Fatalf("expected traces of the targets only, got %v", reconResult.Traces)
+	}
+
+	testCases := []struct {
+		target   string
+		messages []string
+	}{
+		{
+			// traced when b1 is read, and when its counterpart a1 is read
+			target: "b1",
+			messages: []string{
+				"read transaction b1",
+				"filter *transaction_test.testFilter: pass=true",
+				"filter *filter.AllPassFilter: pass=true",
+				"found counterpart a1 by matching key k1",
+				"compared amount by transaction_test.testComparator",
+				"classified as matched by default rules",
+				"read transaction a1, traced by its counterpart",
+				"found counterpart b1 by matching key k1",
+				"compared amount by transaction_test.testComparator",
+				"classified as matched by default rules",
+			},
+		},
+		{
+			target: "k2",
+			messages: []string{
+				"read transaction a2",
+				"filter *transaction_test.testFilter: pass=false",
+				"filter *filter.AllPassFilter: pass=false",
+			},
+		},
+		{
+			target: "k3",
+			messages: []string{
+				"read transaction a3",
+				"filter *transaction_test.testFilter failed: bad timestamp",
+				"filter *filter.AllPassFilter failed: bad timestamp",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		trace, found := reconResult.Traces[tc.target]
+		if !found {
+			t.Fatalf("trace of %s not found", tc.target)
+		}
+
+		messages := make([]string, 0, len(trace.Steps))
+		for _, step := range trace.Steps {
+			messages = append(messages, step.Message)
+		}
+
+		if strings.Join(messages, "\n") != strings.Join(tc.messages, "\n") {
+			t.Fatalf("unexpected trace of %s:\n%s", tc.target, strings.Join(messages, "\n"))
+		}
+	}
+}
+
+// testBalanceChecker records the IDs of the transactions added.
+type testBalanceChecker struct {
+	added []string
+}
+
+func (c *testBalanceChecker) Add(_ context.Context, transaction domain.Transaction) error {
+	c.added = append(c.added, transaction.GetID())
+
+	return nil
+}
+
+func (c *testBalanceChecker) Check(_ context.Context) ([]*domain.BalanceReconResult, error) {
+	return nil, nil
+}
+
+func Test_Reconciler_ErrorPolicy(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name        string
+		errorPolicy transaction.ErrorPolicy
+		records     []*testRecord
+		failed      bool
+		errors      int
+		added       []string
+	}{
+		{
+			name:        "fail fast on malformed row",
+			errorPolicy: transaction.ErrorPolicyFailFast,
+			records:     []*testRecord{{ID: "a1", Key: "k1", Amount: "10"}, nil},
+			failed:      true,
+		},
+		{
+			name:        "fail fast on reconcile error",
+			errorPolicy: transaction.ErrorPolicyFailFast,
+			records:     []*testRecord{{ID: "a1", Key: "k1", Amount: "NaN"}},
+			failed:      true,
+		},
+		{
+			name:        "continue on malformed row",
+			errorPolicy: transaction.ErrorPolicyContinue,
+			records:     []*testRecord{{ID: "a1", Key: "k1", Amount: "10"}, nil, {ID: "a2", Key: "k2", Amount: "20"}},
+			errors:      1,
+			added:       []string{"a1", "a2"},
+		},
+		{
+			name:        "continue on reconcile error without counting the movement",
+			errorPolicy: transaction.ErrorPolicyContinue,
+			records:     []*testRecord{{ID: "a1", Key: "k1", Amount: "10"}, {ID: "a2", Key: "k2", Amount: "NaN"}},
+			errors:      1,
+			added:       []string{"a1"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			balanceChecker := &testBalanceChecker{}
+
+			reconResult, err := newTestReconciler(newTestCollection(tc.records...), newTestCollection()).
+				WithErrorPolicy(tc.errorPolicy).
+				WithParty1BalanceChecker(balanceChecker).
+				Process(context.Background())
+
+			if tc.failed {
+				var recordError *transaction.RecordError
+				if !errors.As(err, &recordError) {
+					t.Fatalf("expected record error, got %v", err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("failed to reconcile: %v", err)
+			}
+
+			if len(reconResult.Errors) != tc.errors {
+				t.Fatalf("expected %d errors, got %v", tc.errors, reconResult.Errors)
+			}
+
+			if diff := cmp.Diff(tc.added, balanceChecker.added); diff != "" {
+				t.Fatalf("unexpected movements (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// testCancelListener cancels the run after the given number of results.
+type testCancelListener struct {
+	transaction.NopListener
+
+	cancel  context.CancelFunc
+	after   int
+	results int
+}
+
+func (l *testCancelListener) OnResult(_ context.Context, _ *domain.TxReconResult) {
+	l.results++
+
+	if l.results == l.after {
+		l.cancel()
+	}
+}
+
+// getResultTypes returns the result types by result key.
+func getResultTypes(reconResult *transaction.ReconResult) map[string]string {
+	resultTypes := make(map[string]string)
+
+	for prefix, results := range map[string]map[string]*domain.TxReconResult{
+		"both":   reconResult.BothParties,
+		"party1": reconResult.Party1Only,
+		"party2": reconResult.Party2Only,
+	} {
+		for key, result := range results {
+			resultTypes[prefix+"/"+key] = result.ResultType
+		}
+	}
+
+	return resultTypes
+}
+
+func Test_Reconciler_Checkpoint(t *testing.T) {
+	t.Parallel()
+
+	records1 := []*testRecord{
+		{ID: "a1", Key: "k1", Amount: "10"},
+		{ID: "a2", Key: "k2", Amount: "20"},
+		nil,
+		{ID: "a3", Key: "k3", Amount: "30"},
+		{ID: "a5", Key: "k5", Amount: "50"},
+	}
+	records2 := []*testRecord{
+		{ID: "b1", Key: "k1", Amount: "10"},
+		{ID: "b2", Key: "k2", Amount: "21"},
+		{ID: "b4", Key: "k4", Amount: "40"},
+		{ID: "b5", Key: "k5", Amount: "50"},
+	}
+
+	expected, err := newTestReconciler(newTestCollection(records1...), newTestCollection(records2...)).
+		WithErrorPolicy(transaction.ErrorPolicyContinue).
+		Process(context.Background())
+	if err != nil {
+		t.Fatalf("failed to reconcile: %v", err)
+	}
+
+	testCases := []struct {
+		name    string
+		records []*testRecord // party1 records of the resumed run
+		err     bool
+	}{
+		{
+			name:    "same input",
+			records: records1,
+		},
+		{
+			name:    "changed input",
+			records: append([]*testRecord{{ID: "a0", Key: "k0", Amount: "0"}}, records1...),
+			err:     true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			store := checkpoint.NewFileCheckpointStore(slog.Default(), t.TempDir())
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			_, err := newTestReconciler(newTestCollection(records1...), newTestCollection(records2...)).
+				WithErrorPolicy(transaction.ErrorPolicyContinue).
+				WithCheckpoint(store, "run1", 2).
+				WithListener(&testCancelListener{cancel: cancel, after: 5}).
+				Process(ctx)
+			if !errors.Is(err, context.Canceled) {
+				t.Fatalf("expected run to be cancelled, got %v", err)
+			}
+
+			listener := &testListener{}
+
+			reconResult, err := newTestReconciler(newTestCollection(tc.records...), newTestCollection(records2...)).
+				WithErrorPolicy(transaction.ErrorPolicyContinue).
+				WithCheckpoint(store, "run1", 2).
+				WithListener(listener).
+				Process(context.Background())
+
+			if tc.err {
+				var mismatchError *transaction.CheckpointMismatchError
+				if !errors.As(err, &mismatchError) {
+					t.Fatalf("expected checkpoint mismatch, got %v", err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("failed to resume: %v", err)
+			}
+
+			if diff := cmp.Diff(getResultTypes(expected), getResultTypes(reconResult)); diff != "" {
+				t.Fatalf("unexpected results (-want +got):\n%s", diff)
+			}
+
+			// results restored from the checkpoint are notified as well
+			if listener.results != len(getResultTypes(expected)) {
+				t.Fatalf("expected %d results notified, got %d", len(getResultTypes(expected)), listener.results)
+			}
+
+			if len(reconResult.Errors) != len(expected.Errors) {
+				t.Fatalf("expected %d errors, got %v", len(expected.Errors), reconResult.Errors)
+			}
+
+			_, found, err := store.Load(context.Background(), "run1")
+			if err != nil || found {
+				t.Fatalf("expected checkpoint deleted, got found=%v err=%v", found, err)
+			}
+		})
+	}
+}
+
+// testListener records the lifecycle events.
+type testListener struct {
+	events  []string
+	results int
+}
+
+func (l *testListener) OnRunStart(_ context.Context, _ *transaction.RunStartEvent) {
+	l.events = append(l.events, "run start")
+}
+
+func (l *testListener) OnRunEnd(_ context.Context, event *transaction.RunEndEvent) {
+	l.events = append(l.events, fmt.Sprintf("run end: %d matched, %d party2 only", event.Count.Matched, event.Count.Party2Only))
+}
+
+func (l *testListener) OnCollectionOpened(_ context.Context, event *transaction.CollectionEvent) {
+	l.events = append(l.events, fmt.Sprintf("opened %s: %d records", event.PartyID, event.RecordCount))
+}
+
+func (l *testListener) OnCollectionClosed(_ context.Context, event *transaction.CollectionEvent) {
+	l.events = append(l.events, fmt.Sprintf("closed %s: %d records", event.PartyID, event.RecordCount))
+}
+
+func (l *testListener) OnResult(_ context.Context, _ *domain.TxReconResult) {
+	l.results++
+}
+
+func (l *testListener) OnError(_ context.Context, err error) {
+	l.events = append(l.events, "error: "+err.Error())
+}
+
+func Test_Reconciler_Listener(t *testing.T) {
+	t.Parallel()
+
+	listener := &testListener{}
+
+	col2 := collection.NewInMemoryCollection[*testRecord](&testReader{
+		records:  []*testRecord{{ID: "b1", Key: "k1", Amount: "10"}, {ID: "b2", Key: "k2", Amount: "20"}},
+		closeErr: errors.New("connection reset"),
+	})
+
+	_, err := newTestReconciler(newTestCollection(&testRecord{ID: "a1", Key: "k1", Amount: "10"}), col2).
+		WithListener(listener).
+		Process(context.Background())
+	if err != nil {
+		t.Fatalf("failed to reconcile: %v", err)
+	}
+
+	expected := []string{
+		"run start",
+		"opened party1: 1 records",
+		"opened party2: 2 records",
+		// a failed close is reported, and the collection is closed anyway
+		"error: could not close collection of party2: connection reset",
+		"closed party2: 2 records",
+		"closed party1: 1 records",
+		"run end: 1 matched, 1 party2 only",
+	}
+
+	if diff := cmp.Diff(expected, listener.events); diff != "" {
+		t.Fatalf("unexpected events (-want +got):\n%s", diff)
+	}
+
+	if listener.results != 2 {
+		t.Fatalf("expected 2 results, got %d", listener.results)
+	}
+}
+
+func Test_Reconciler_Progress(t *testing.T) {
+	t.Parallel()
+
+	var reports []*transaction.Progress
+
+	rc := newTestReconciler(
+		newTestCollection(&testRecord{ID: "a1", Key: "k1", Amount: "10"}),
+		newTestCollection(&testRecord{ID: "b1", Key: "k1", Amount: "10"}, &testRecord{ID: "b2", Key: "k2", Amount: "20"}),
+	).WithProgress(time.Hour, func(_ context.Context, progress *transaction.Progress) {
+		reports = append(reports, progress)
+	})
+
+	// the counts of a run do not carry over to the next one
+	for range 2 {
+		_, err := rc.Process(context.Background())
+		if err != nil {
+			t.Fatalf("failed to reconcile: %v", err)
+		}
+	}
+
+	if len(reports) != 2 {
+		t.Fatalf("expected a final report per run, got %d", len(reports))
+	}
+
+	for _, progress := range reports {
+		if progress.RecordsProcessed != 3 || progress.RecordsTotal != 3 || progress.Fraction != 1 {
+			t.Fatalf("unexpected progress %+v", progress)
+		}
+	}
+}
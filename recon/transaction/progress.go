@@ -0,0 +1,63 @@
+package transaction
+
+import (
+	"context"
+	"time"
+
+	"github.com/ivxivx/go-recon/batch"
+)
+
+type Progress struct {
+	Elapsed time.Duration
+	// progress of loading the collections
+	Party1 batch.Progress
+	Party2 batch.Progress
+	// records of both collections compared so far
+	RecordsProcessed int64
+	RecordsTotal     int64 // -1 if unknown
+	// 0 to 1, loading and comparing weigh a half each, -1 if unknown
+	Fraction float64
+	ETA      time.Duration // -1 if unknown
+}
+
+type ProgressFunc func(ctx context.Context, progress *Progress)
+
+func getCollectionProgress(col Collection) batch.Progress {
+	if reporter, cok := col.(batch.ProgressReporter); cok {
+		return reporter.GetProgress()
+	}
+
+	return batch.Progress{TotalBytes: -1}
+}
+
+func (p *Progress) estimate() {
+	p.Fraction = -1
+	p.ETA = -1
+
+	var loadFraction float64
+
+	switch {
+	case p.Party1.TotalBytes >= 0 && p.Party2.TotalBytes >= 0 && p.Party1.TotalBytes+p.Party2.TotalBytes > 0:
+		loadFraction = float64(p.Party1.BytesRead+p.Party2.BytesRead) / float64(p.Party1.TotalBytes+p.Party2.TotalBytes)
+	case p.RecordsTotal >= 0:
+		// collections are loaded
+		loadFraction = 1
+	default:
+		return
+	}
+
+	var compareFraction float64
+
+	switch {
+	case p.RecordsTotal > 0:
+		compareFraction = float64(p.RecordsProcessed) / float64(p.RecordsTotal)
+	case p.RecordsTotal == 0:
+		compareFraction = 1
+	}
+
+	p.Fraction = min((min(loadFraction, 1)+min(compareFraction, 1))/2, 1)
+
+	if p.Fraction > 0 {
+		p.ETA = time.Duration(float64(p.Elapsed) * (1 - p.Fraction) / p.Fraction)
+	}
+}
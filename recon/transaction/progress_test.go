@@ -0,0 +1,70 @@
+package transaction
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ivxivx/go-recon/batch"
+)
+
+func Test_Progress_Estimate(t *testing.T) {
+	t.Parallel()
+
+	unknown := batch.Progress{TotalBytes: -1}
+
+	testCases := []struct {
+		name     string
+		progress Progress
+		fraction float64
+		eta      time.Duration
+	}{
+		{
+			name:     "nothing known",
+			progress: Progress{Elapsed: time.Minute, Party1: unknown, Party2: unknown, RecordsTotal: -1},
+			fraction: -1,
+			eta:      -1,
+		},
+		{
+			name: "loading",
+			progress: Progress{
+				Elapsed:      time.Minute,
+				Party1:       batch.Progress{TotalBytes: 100, BytesRead: 100},
+				Party2:       batch.Progress{TotalBytes: 100},
+				RecordsTotal: -1,
+			},
+			fraction: 0.25,
+			eta:      3 * time.Minute,
+		},
+		{
+			name:     "comparing without sizes",
+			progress: Progress{Elapsed: time.Minute, Party1: unknown, Party2: unknown, RecordsProcessed: 50, RecordsTotal: 100},
+			fraction: 0.75,
+			eta:      20 * time.Second,
+		},
+		{
+			name:     "empty collections",
+			progress: Progress{Elapsed: time.Minute, Party1: unknown, Party2: unknown},
+			fraction: 1,
+			eta:      0,
+		},
+		{
+			name:     "nothing done yet",
+			progress: Progress{Party1: batch.Progress{TotalBytes: 100}, Party2: batch.Progress{TotalBytes: 100}, RecordsTotal: -1},
+			fraction: 0,
+			eta:      -1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			progress := tc.progress
+			progress.estimate()
+
+			if progress.Fraction != tc.fraction || progress.ETA != tc.eta {
+				t.Fatalf("expected fraction %v and eta %v, got %v and %v", tc.fraction, tc.eta, progress.Fraction, progress.ETA)
+			}
+		})
+	}
+}
@@ -6,8 +6,13 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"sort"
+	"sync/atomic"
 	"time"
 
+	"github.com/shopspring/decimal"
+
+	"github.com/ivxivx/go-recon/batch"
 	"github.com/ivxivx/go-recon/recon"
 	"github.com/ivxivx/go-recon/recon/domain"
 )
@@ -20,6 +25,34 @@ type Reconciler[T1, T2 domain.Transaction] struct {
 	party2TxCollection Collection
 	filter             Filter
 	comparator         Comparator
+	classifier         ResultClassifier
+
+	party1BalanceChecker BalanceChecker
+	party2BalanceChecker BalanceChecker
+
+	pendingStore PendingStore
+	pendingSLA   time.Duration
+
+	scorer Scorer
+
+	traceTargets map[string]struct{}
+
+	errorPolicy ErrorPolicy
+
+	checkpointStore    CheckpointStore
+	runID              string
+	checkpointInterval int
+	sink               ResultSink
+	delta              *checkpointDelta
+
+	listeners []Listener
+
+	progressInterval time.Duration
+	progressFunc     ProgressFunc
+	recordsProcessed atomic.Int64
+	recordsTotal     atomic.Int64
+
+	now func() time.Time
 }
 
 func NewReconciler[T1, T2 domain.Transaction](
@@ -35,6 +68,8 @@ func NewReconciler[T1, T2 domain.Transaction](
 		party1TxCollection: party1TxCollection,
 		party2TxCollection: party2TxCollection,
 		comparator:         comparator,
+		errorPolicy:        ErrorPolicyFailFast,
+		now:                time.Now,
 	}
 }
 
@@ -44,6 +79,92 @@ func (rc *Reconciler[T1, T2]) WithFilter(filter Filter) *Reconciler[T1, T2] {
 	return rc
 }
 
+func (rc *Reconciler[T1, T2]) WithParty1BalanceChecker(checker BalanceChecker) *Reconciler[T1, T2] {
+	rc.party1BalanceChecker = checker
+
+	return rc
+}
+
+func (rc *Reconciler[T1, T2]) WithParty2BalanceChecker(checker BalanceChecker) *Reconciler[T1, T2] {
+	rc.party2BalanceChecker = checker
+
+	return rc
+}
+
+func (rc *Reconciler[T1, T2]) WithResultClassifier(classifier ResultClassifier) *Reconciler[T1, T2] {
+	rc.classifier = classifier
+
+	return rc
+}
+
+// WithPendingStore enables pending handling: a transaction in a non-terminal state is reported as pending
+// instead of a break, until it stays unresolved for longer than sla. A filter must let non-terminal
+// statuses pass, e.g. the status filters of the parties only pass terminal statuses by default.
+func (rc *Reconciler[T1, T2]) WithPendingStore(store PendingStore, sla time.Duration) *Reconciler[T1, T2] {
+	rc.pendingStore = store
+	rc.pendingSLA = sla
+
+	return rc
+}
+
+func (rc *Reconciler[T1, T2]) WithScorer(scorer Scorer) *Reconciler[T1, T2] {
+	rc.scorer = scorer
+
+	return rc
+}
+
+// WithTrace enables trace mode for the given matching keys or transaction IDs,
+// every decision made for them is recorded in ReconResult.Traces.
+func (rc *Reconciler[T1, T2]) WithTrace(targets ...string) *Reconciler[T1, T2] {
+	rc.traceTargets = make(map[string]struct{}, len(targets))
+
+	for _, target := range targets {
+		rc.traceTargets[target] = struct{}{}
+	}
+
+	return rc
+}
+
+func (rc *Reconciler[T1, T2]) WithErrorPolicy(errorPolicy ErrorPolicy) *Reconciler[T1, T2] {
+	rc.errorPolicy = errorPolicy
+
+	return rc
+}
+
+// WithCheckpoint saves the progress of the run every interval records, a restarted run with the same run ID
+// resumes from the last checkpoint. Each save holds what changed since the previous one. A run does not resume
+// if a collection implementing Fingerprinter has changed since. Results emitted to the sink after the last
+// checkpoint are emitted again.
+func (rc *Reconciler[T1, T2]) WithCheckpoint(store CheckpointStore, runID string, interval int) *Reconciler[T1, T2] {
+	rc.checkpointStore = store
+	rc.runID = runID
+	rc.checkpointInterval = interval
+
+	return rc
+}
+
+// WithSink emits every result to the sink once it is reconciled.
+func (rc *Reconciler[T1, T2]) WithSink(sink ResultSink) *Reconciler[T1, T2] {
+	rc.sink = sink
+
+	return rc
+}
+
+// WithListener registers listeners of the lifecycle of a run.
+func (rc *Reconciler[T1, T2]) WithListener(listeners ...Listener) *Reconciler[T1, T2] {
+	rc.listeners = append(rc.listeners, listeners...)
+
+	return rc
+}
+
+// WithProgress calls fn with the progress of the run every interval, and once when the run ends.
+func (rc *Reconciler[T1, T2]) WithProgress(interval time.Duration, fn ProgressFunc) *Reconciler[T1, T2] {
+	rc.progressInterval = interval
+	rc.progressFunc = fn
+
+	return rc
+}
+
 type ReconResult struct {
 	// matching key -> result
 	BothParties map[string]*domain.TxReconResult
@@ -51,97 +172,540 @@ type ReconResult struct {
 	Party1Only map[string]*domain.TxReconResult
 	// party2 transaction id -> result
 	Party2Only map[string]*domain.TxReconResult
+	// run-level balance checks of both parties
+	Balances []*domain.BalanceReconResult
+	// trace target -> trace, only in trace mode
+	Traces map[string]*Trace
+	// errors of skipped records, only with ErrorPolicyContinue
+	Errors []*RecordError
 }
 
 type ReconResultCount struct {
-	Matched    int
-	Mismatched int
-	Party1Only int
-	Party2Only int
+	Matched           int
+	Mismatched        int
+	Party1Only        int
+	Party2Only        int
+	Pending           int
+	BalanceMismatched int
+	Errors            int
 }
 
 func (rr *ReconResult) GetCount() ReconResultCount {
-	var matchedCount, mismatchedCount int
+	var matchedCount, mismatchedCount, pendingCount, party1OnlyCount, party2OnlyCount int
 
 	for _, txReconResult := range rr.BothParties {
-		if txReconResult.ResultType == recon.ResultMatched {
+		switch txReconResult.ResultType {
+		case recon.ResultMatched:
 			matchedCount++
-		} else {
+		case recon.ResultPending:
+			pendingCount++
+		default:
 			mismatchedCount++
 		}
 	}
 
+	for _, txReconResult := range rr.Party1Only {
+		if txReconResult.ResultType == recon.ResultPending {
+			pendingCount++
+		} else {
+			party1OnlyCount++
+		}
+	}
+
+	for _, txReconResult := range rr.Party2Only {
+		if txReconResult.ResultType == recon.ResultPending {
+			pendingCount++
+		} else {
+			party2OnlyCount++
+		}
+	}
+
+	var balanceMismatchedCount int
+
+	for _, balanceResult := range rr.Balances {
+		if balanceResult.ResultType != recon.ResultBalanceMatched {
+			balanceMismatchedCount++
+		}
+	}
+
 	return ReconResultCount{
-		Matched:    matchedCount,
-		Mismatched: mismatchedCount,
-		Party1Only: len(rr.Party1Only),
-		Party2Only: len(rr.Party2Only),
+		Matched:           matchedCount,
+		Mismatched:        mismatchedCount,
+		Party1Only:        party1OnlyCount,
+		Party2Only:        party2OnlyCount,
+		Pending:           pendingCount,
+		BalanceMismatched: balanceMismatchedCount,
+		Errors:            len(rr.Errors),
 	}
 }
 
+// GetBreaksBySeverity returns all breaks, i.e. neither matched nor pending, with the most severe first.
+// Unscorable breaks come first, since their severity is unknown.
+func (rr *ReconResult) GetBreaksBySeverity() []*domain.TxReconResult {
+	breaks := make([]*domain.TxReconResult, 0, len(rr.BothParties)+len(rr.Party1Only)+len(rr.Party2Only))
+
+	for _, results := range []map[string]*domain.TxReconResult{rr.BothParties, rr.Party1Only, rr.Party2Only} {
+		for _, txReconResult := range results {
+			if txReconResult.ResultType == recon.ResultMatched || txReconResult.ResultType == recon.ResultPending {
+				continue
+			}
+
+			breaks = append(breaks, txReconResult)
+		}
+	}
+
+	sort.SliceStable(breaks, func(i, j int) bool {
+		unscorable1, unscorable2 := breaks[i].UnscorableReason != "", breaks[j].UnscorableReason != ""
+		if unscorable1 != unscorable2 {
+			return unscorable1
+		}
+
+		severity1, severity2 := getSeverity(breaks[i]), getSeverity(breaks[j])
+		if !severity1.Equal(severity2) {
+			return severity1.GreaterThan(severity2)
+		}
+
+		return breaks[i].MatchingKey < breaks[j].MatchingKey
+	})
+
+	return breaks
+}
+
+func getSeverity(txReconResult *domain.TxReconResult) decimal.Decimal {
+	if txReconResult.Severity == nil {
+		return decimal.Zero
+	}
+
+	return *txReconResult.Severity
+}
+
 func (rc *Reconciler[T1, T2]) Process(ctx context.Context) (*ReconResult, error) {
-	err := rc.party1TxCollection.Open(ctx)
-	if err != nil {
-		return nil, err
+	startedAt := rc.now()
+
+	for _, listener := range rc.listeners {
+		listener.OnRunStart(ctx, &RunStartEvent{
+			RunID:     rc.runID,
+			Party1ID:  rc.party1ID,
+			Party2ID:  rc.party2ID,
+			StartedAt: startedAt,
+		})
 	}
 
-	defer func() {
-		if errC := rc.party1TxCollection.Close(ctx); errC != nil {
-			rc.logger.Warn("failed to close collection1", slog.Any("error", errC))
+	// reset before the first report, so that it does not show the counts of a previous run
+	rc.recordsProcessed.Store(0)
+	rc.recordsTotal.Store(-1)
 
-			return
+	stopProgress := rc.reportProgress(ctx, startedAt)
+
+	reconResult, err := rc.process(ctx)
+
+	stopProgress()
+
+	endEvent := &RunEndEvent{
+		RunID:     rc.runID,
+		StartedAt: startedAt,
+		EndedAt:   rc.now(),
+		Err:       err,
+	}
+
+	if err == nil {
+		endEvent.Count = reconResult.GetCount()
+	}
+
+	for _, listener := range rc.listeners {
+		if err != nil {
+			listener.OnError(ctx, err)
 		}
-	}()
 
-	err = rc.party2TxCollection.Open(ctx)
+		listener.OnRunEnd(ctx, endEvent)
+	}
+
+	return reconResult, err
+}
+
+func (rc *Reconciler[T1, T2]) process(ctx context.Context) (*ReconResult, error) {
+	if store, cok := rc.pendingStore.(batch.OpenCloser); cok {
+		err := store.Open(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("could not open pending store: %w", err)
+		}
+
+		defer func() {
+			if errC := store.Close(ctx); errC != nil {
+				rc.logger.Warn("failed to close pending store", slog.Any("error", errC))
+			}
+		}()
+	}
+
+	// balance checkers sum the movements of one run
+	for _, checker := range []BalanceChecker{rc.party1BalanceChecker, rc.party2BalanceChecker} {
+		if opener, cok := checker.(batch.OpenCloser); cok {
+			err := opener.Open(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("could not open balance checker: %w", err)
+			}
+
+			defer func() {
+				if errC := opener.Close(ctx); errC != nil {
+					rc.logger.Warn("failed to close balance checker", slog.Any("error", errC))
+				}
+			}()
+		}
+	}
+
+	// number of records processed per phase
+	var processed [phaseDone]int
+
+	err := rc.openCollection(ctx, rc.party1ID, rc.party1TxCollection)
 	if err != nil {
 		return nil, err
 	}
 
-	defer func() {
-		if errC := rc.party2TxCollection.Close(ctx); errC != nil {
-			rc.logger.Warn("failed to close collection2", slog.Any("error", errC))
+	defer rc.closeCollection(ctx, rc.party1ID, rc.party1TxCollection, &processed[phaseParty1])
 
-			return
-		}
-	}()
+	err = rc.openCollection(ctx, rc.party2ID, rc.party2TxCollection)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rc.closeCollection(ctx, rc.party2ID, rc.party2TxCollection, &processed[phaseParty2])
+
+	counter1, cok1 := rc.party1TxCollection.(Counter)
+	counter2, cok2 := rc.party2TxCollection.(Counter)
+
+	if cok1 && cok2 {
+		rc.recordsTotal.Store(int64(counter1.Count() + counter2.Count()))
+	}
 
 	reconResult := &ReconResult{
 		BothParties: make(map[string]*domain.TxReconResult),
 		Party1Only:  make(map[string]*domain.TxReconResult),
 		Party2Only:  make(map[string]*domain.TxReconResult),
+		Traces:      make(map[string]*Trace),
 	}
 
-	err = rc.compareParty2AgainstParty1(ctx, reconResult)
+	if rc.checkpointStore != nil {
+		rc.delta = newCheckpointDelta()
+	}
+
+	startPhase, startPosition, err := rc.restoreCheckpoint(ctx, reconResult)
+	if err != nil {
+		return nil, err
+	}
+
+	// errors of a resumed run are restored from the checkpoint
+	err = rc.checkOpenErrors(ctx, reconResult, startPhase == phaseParty2 && startPosition == 0)
 	if err != nil {
 		return nil, err
 	}
 
-	err = rc.compareParty1AgainstParty2(ctx, reconResult)
+	for _, phase := range []int{phaseParty2, phaseParty1} {
+		skip := 0
+
+		switch {
+		case phase < startPhase:
+			skip = -1
+		case phase == startPhase:
+			skip = startPosition
+		}
+
+		processed[phase], err = rc.compareAll(ctx, reconResult, phase, skip)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = rc.checkBalances(ctx, reconResult)
 	if err != nil {
 		return nil, err
 	}
 
+	if rc.checkpointStore != nil {
+		if errD := rc.checkpointStore.Delete(ctx, rc.runID); errD != nil {
+			rc.logger.Warn("failed to delete checkpoint", slog.String("run_id", rc.runID), slog.Any("error", errD))
+		}
+	}
+
 	return reconResult, nil
 }
 
-func (rc *Reconciler[T1, T2]) compareParty2AgainstParty1(
-	ctx context.Context,
-	reconResult *ReconResult,
-) error {
-loop:
-	for {
-		select {
-		case <-ctx.Done():
-			return fmt.Errorf("context cancelled: %w", ctx.Err())
-		default:
-			err := rc.compare(ctx, reconResult, false)
+// reportProgress reports progress periodically until the returned function is called.
+func (rc *Reconciler[T1, T2]) reportProgress(ctx context.Context, startedAt time.Time) func() {
+	if rc.progressFunc == nil {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+
+		if rc.progressInterval <= 0 {
+			<-done
+
+			return
+		}
+
+		ticker := time.NewTicker(rc.progressInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				rc.progressFunc(ctx, rc.getProgress(startedAt))
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+
+		rc.progressFunc(ctx, rc.getProgress(startedAt))
+	}
+}
+
+func (rc *Reconciler[T1, T2]) getProgress(startedAt time.Time) *Progress {
+	progress := &Progress{
+		Elapsed:          rc.now().Sub(startedAt),
+		Party1:           getCollectionProgress(rc.party1TxCollection),
+		Party2:           getCollectionProgress(rc.party2TxCollection),
+		RecordsProcessed: rc.recordsProcessed.Load(),
+		RecordsTotal:     rc.recordsTotal.Load(),
+	}
+
+	progress.estimate()
+
+	return progress
+}
+
+func (rc *Reconciler[T1, T2]) openCollection(ctx context.Context, partyID string, col Collection) error {
+	err := col.Open(ctx)
+	if err != nil {
+		return err
+	}
+
+	recordCount := -1
+
+	if counter, cok := col.(Counter); cok {
+		recordCount = counter.Count()
+	}
+
+	for _, listener := range rc.listeners {
+		listener.OnCollectionOpened(ctx, &CollectionEvent{PartyID: partyID, RecordCount: recordCount})
+	}
+
+	return nil
+}
+
+func (rc *Reconciler[T1, T2]) closeCollection(ctx context.Context, partyID string, col Collection, processed *int) {
+	// the collection is closed as far as listeners are concerned, a failed close is reported as an error
+	if errC := col.Close(ctx); errC != nil {
+		rc.logger.Warn("failed to close collection", slog.String("party", partyID), slog.Any("error", errC))
+
+		err := fmt.Errorf("could not close collection of %s: %w", partyID, errC)
+
+		for _, listener := range rc.listeners {
+			listener.OnError(ctx, err)
+		}
+	}
+
+	for _, listener := range rc.listeners {
+		listener.OnCollectionClosed(ctx, &CollectionEvent{PartyID: partyID, RecordCount: *processed})
+	}
+}
+
+// restoreCheckpoint restores the last checkpoint, it returns where to resume.
+func (rc *Reconciler[T1, T2]) restoreCheckpoint(ctx context.Context, reconResult *ReconResult) (int, int, error) {
+	if rc.checkpointStore == nil {
+		return phaseParty2, 0, nil
+	}
+
+	checkpoint, found, err := rc.checkpointStore.Load(ctx, rc.runID)
+	if err != nil {
+		return 0, 0, fmt.Errorf("could not load checkpoint of run %s: %w", rc.runID, err)
+	}
+
+	if !found {
+		return phaseParty2, 0, nil
+	}
+
+	fingerprints := rc.getFingerprints()
+
+	for _, partyID := range []string{rc.party1ID, rc.party2ID} {
+		if fingerprints[partyID] != checkpoint.Fingerprints[partyID] {
+			return 0, 0, &CheckpointMismatchError{RunID: rc.runID, PartyID: partyID}
+		}
+	}
+
+	rc.logger.Info("resume from checkpoint", slog.String("run_id", rc.runID),
+		slog.Int("phase", checkpoint.Phase), slog.Int("position", checkpoint.Position))
+
+	for matchingKey, txReconResult := range checkpoint.BothParties {
+		reconResult.BothParties[matchingKey] = txReconResult
+	}
+
+	for txID, txReconResult := range checkpoint.Party1Only {
+		reconResult.Party1Only[txID] = txReconResult
+	}
+
+	for txID, txReconResult := range checkpoint.Party2Only {
+		reconResult.Party2Only[txID] = txReconResult
+	}
+
+	// listeners see every result of the run, also those reconciled before the restart
+	for _, results := range []map[string]*domain.TxReconResult{
+		checkpoint.BothParties, checkpoint.Party1Only, checkpoint.Party2Only,
+	} {
+		for _, txReconResult := range results {
+			for _, listener := range rc.listeners {
+				listener.OnResult(ctx, txReconResult)
+			}
+		}
+	}
+
+	for _, checkpointError := range checkpoint.Errors {
+		reconResult.Errors = append(reconResult.Errors, &RecordError{
+			PartyID:       checkpointError.PartyID,
+			TransactionID: checkpointError.TransactionID,
+			MatchingKey:   checkpointError.MatchingKey,
+			Err:           errors.New(checkpointError.Message),
+		})
+	}
+
+	rc.delta.errors = len(reconResult.Errors)
+
+	for target, trace := range checkpoint.Traces {
+		reconResult.Traces[target] = trace
+	}
+
+	// the pending store may not have kept the changes made after its last save
+	if rc.pendingStore != nil {
+		for matchingKey, since := range checkpoint.Pending {
+			if since == nil {
+				err = rc.pendingStore.Delete(ctx, matchingKey)
+			} else {
+				err = rc.pendingStore.Put(ctx, matchingKey, *since)
+			}
+
 			if err != nil {
-				if errors.Is(err, io.EOF) {
-					break loop
-				}
+				return 0, 0, fmt.Errorf("could not restore %s into pending store: %w", matchingKey, err)
+			}
+		}
+	}
+
+	return checkpoint.Phase, checkpoint.Position, nil
+}
+
+// saveCheckpoint saves what changed since the last checkpoint.
+func (rc *Reconciler[T1, T2]) saveCheckpoint(ctx context.Context, reconResult *ReconResult, phase, position int) error {
+	checkpoint := &Checkpoint{
+		RunID:        rc.runID,
+		UpdatedAt:    rc.now(),
+		Phase:        phase,
+		Position:     position,
+		Fingerprints: rc.getFingerprints(),
+		BothParties:  getChangedResults(reconResult.BothParties, rc.delta.bothParties),
+		Party1Only:   getChangedResults(reconResult.Party1Only, rc.delta.party1Only),
+		Party2Only:   getChangedResults(reconResult.Party2Only, rc.delta.party2Only),
+		Errors:       make([]*CheckpointError, 0, len(reconResult.Errors)-rc.delta.errors),
+		Pending:      rc.delta.pending,
+		Traces:       reconResult.Traces,
+	}
+
+	for _, recordError := range reconResult.Errors[rc.delta.errors:] {
+		checkpoint.Errors = append(checkpoint.Errors, &CheckpointError{
+			PartyID:       recordError.PartyID,
+			TransactionID: recordError.TransactionID,
+			MatchingKey:   recordError.MatchingKey,
+			Message:       recordError.Err.Error(),
+		})
+	}
+
+	err := rc.checkpointStore.Save(ctx, checkpoint)
+	if err != nil {
+		return fmt.Errorf("could not save checkpoint of run %s: %w", rc.runID, err)
+	}
+
+	errorCount := len(reconResult.Errors)
+
+	rc.delta = newCheckpointDelta()
+	rc.delta.errors = errorCount
+
+	return nil
+}
+
+func getChangedResults(
+	results map[string]*domain.TxReconResult,
+	changed map[string]struct{},
+) map[string]*domain.TxReconResult {
+	changedResults := make(map[string]*domain.TxReconResult, len(changed))
+
+	for key := range changed {
+		changedResults[key] = results[key]
+	}
+
+	return changedResults
+}
+
+// getFingerprints returns the fingerprints of the collections which have one.
+func (rc *Reconciler[T1, T2]) getFingerprints() map[string]string {
+	fingerprints := make(map[string]string)
+
+	if fingerprinter, cok := rc.party1TxCollection.(Fingerprinter); cok {
+		fingerprints[rc.party1ID] = fingerprinter.Fingerprint()
+	}
+
+	if fingerprinter, cok := rc.party2TxCollection.(Fingerprinter); cok {
+		fingerprints[rc.party2ID] = fingerprinter.Fingerprint()
+	}
+
+	return fingerprints
+}
+
+func (rc *Reconciler[T1, T2]) checkBalances(ctx context.Context, reconResult *ReconResult) error {
+	for _, checker := range []BalanceChecker{rc.party1BalanceChecker, rc.party2BalanceChecker} {
+		if checker == nil {
+			continue
+		}
+
+		balanceResults, err := checker.Check(ctx)
+		if err != nil {
+			return fmt.Errorf("could not check balances: %w", err)
+		}
+
+		reconResult.Balances = append(reconResult.Balances, balanceResults...)
+	}
+
+	return nil
+}
+
+// checkOpenErrors checks errors of records skipped by collections while opening, it fails with
+// ErrorPolicyFailFast, otherwise it collects them if collect is true.
+func (rc *Reconciler[T1, T2]) checkOpenErrors(ctx context.Context, reconResult *ReconResult, collect bool) error {
+	for _, partyID := range []string{rc.party1ID, rc.party2ID} {
+		col := rc.party1TxCollection
+		if partyID == rc.party2ID {
+			col = rc.party2TxCollection
+		}
+
+		errorCollector, cok := col.(ErrorCollector)
+		if !cok {
+			continue
+		}
+
+		for _, err := range errorCollector.GetErrors() {
+			recordError := &RecordError{PartyID: partyID, Err: err}
+
+			if rc.errorPolicy != ErrorPolicyContinue {
+				return recordError
+			}
 
-				return err
+			if collect {
+				rc.collectError(ctx, reconResult, recordError)
 			}
 		}
 	}
@@ -149,54 +713,204 @@ loop:
 	return nil
 }
 
-func (rc *Reconciler[T1, T2]) compareParty1AgainstParty2(
+// compareAll iterates the collection of the phase, the first skip records (all if negative) are already
+// reconciled before the checkpoint, they are only read to restore the balances.
+func (rc *Reconciler[T1, T2]) compareAll(
 	ctx context.Context,
 	reconResult *ReconResult,
-) error {
+	phase int,
+	skip int,
+) (int, error) {
+	isParty1 := phase == phaseParty1
+
+	if skip < 0 && rc.getBalanceChecker(isParty1) == nil {
+		return 0, nil
+	}
+
+	var position, consecutiveReadErrors int
+
+	var failed map[string]struct{}
+
+	if skip != 0 {
+		failed = rc.getFailedTransactions(reconResult, isParty1)
+	}
+
 loop:
 	for {
 		select {
 		case <-ctx.Done():
-			return fmt.Errorf("context cancelled: %w", ctx.Err())
+			return position, fmt.Errorf("context cancelled: %w", ctx.Err())
 		default:
-			err := rc.compare(ctx, reconResult, true)
+			var err error
+
+			if skip < 0 || position < skip {
+				err = rc.skip(ctx, isParty1, failed)
+			} else {
+				err = rc.compare(ctx, reconResult, isParty1)
+			}
+
 			if err != nil {
 				if errors.Is(err, io.EOF) {
 					break loop
 				}
 
-				return err
+				var recordError *RecordError
+				if rc.errorPolicy != ErrorPolicyContinue || !errors.As(err, &recordError) ||
+					errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+					return position, err
+				}
+
+				if recordError.TransactionID == "" {
+					consecutiveReadErrors++
+
+					if consecutiveReadErrors >= MaxConsecutiveReadErrors {
+						return position, fmt.Errorf("too many consecutive read errors: %w", err)
+					}
+				} else {
+					consecutiveReadErrors = 0
+				}
+
+				rc.logger.Warn("skip record", slog.Any("error", err))
+
+				rc.collectError(ctx, reconResult, recordError)
+			} else {
+				consecutiveReadErrors = 0
+			}
+
+			position++
+
+			rc.recordsProcessed.Add(1)
+
+			if rc.checkpointStore != nil && rc.checkpointInterval > 0 && skip >= 0 && position > skip &&
+				position%rc.checkpointInterval == 0 {
+				err = rc.saveCheckpoint(ctx, reconResult, phase, position)
+				if err != nil {
+					return position, err
+				}
 			}
 		}
 	}
 
+	if rc.checkpointStore != nil && skip >= 0 {
+		return position, rc.saveCheckpoint(ctx, reconResult, phase+1, 0)
+	}
+
+	return position, nil
+}
+
+func (rc *Reconciler[T1, T2]) collectError(ctx context.Context, reconResult *ReconResult, recordError *RecordError) {
+	reconResult.Errors = append(reconResult.Errors, recordError)
+
+	for _, listener := range rc.listeners {
+		listener.OnError(ctx, recordError)
+	}
+}
+
+// getFailedTransactions returns IDs of transactions of the party which failed before the checkpoint.
+func (rc *Reconciler[T1, T2]) getFailedTransactions(reconResult *ReconResult, isParty1 bool) map[string]struct{} {
+	partyID := rc.party2ID
+	if isParty1 {
+		partyID = rc.party1ID
+	}
+
+	failed := make(map[string]struct{})
+
+	for _, recordError := range reconResult.Errors {
+		if recordError.PartyID == partyID && recordError.TransactionID != "" {
+			failed[recordError.TransactionID] = struct{}{}
+		}
+	}
+
+	return failed
+}
+
+// skip reads a transaction which is already reconciled, and adds it to the balance checker unless it failed.
+func (rc *Reconciler[T1, T2]) skip(ctx context.Context, isParty1 bool, failed map[string]struct{}) error {
+	partyTransaction1, err := rc.read(ctx, isParty1)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return err
+		}
+
+		// the error is restored from the checkpoint
+		return nil
+	}
+
+	if _, found := failed[partyTransaction1.GetID()]; found {
+		return nil
+	}
+
+	balanceChecker := rc.getBalanceChecker(isParty1)
+	if balanceChecker == nil {
+		return nil
+	}
+
+	if rc.filter != nil {
+		pass, errF := rc.filter.Filter(ctx, partyTransaction1)
+		if errF != nil || !pass {
+			return nil
+		}
+	}
+
+	// errors are restored from the checkpoint
+	_ = balanceChecker.Add(ctx, partyTransaction1)
+
 	return nil
 }
 
+func (rc *Reconciler[T1, T2]) getBalanceChecker(isParty1 bool) BalanceChecker {
+	if isParty1 {
+		return rc.party1BalanceChecker
+	}
+
+	return rc.party2BalanceChecker
+}
+
 func (rc *Reconciler[T1, T2]) compare(
 	ctx context.Context,
 	reconResult *ReconResult,
 	isParty1 bool,
 ) error {
+	partyTransaction1, err := rc.read(ctx, isParty1)
+	if err != nil {
+		return err
+	}
+
+	err = rc.reconcile(ctx, reconResult, isParty1, partyTransaction1)
+	if err != nil {
+		partyID := rc.party2ID
+		if isParty1 {
+			partyID = rc.party1ID
+		}
+
+		return &RecordError{
+			PartyID:       partyID,
+			TransactionID: partyTransaction1.GetID(),
+			MatchingKey:   partyTransaction1.GetMatchingKey(),
+			Record:        partyTransaction1,
+			Err:           err,
+		}
+	}
+
+	return nil
+}
+
+func (rc *Reconciler[T1, T2]) read(ctx context.Context, isParty1 bool) (domain.Transaction, error) {
 	var partyTransaction1 domain.Transaction
 
-	var notFoundResultType string
+	var partyCollection1 Collection
 
-	var partyCollection1, partyCollection2 Collection
+	var partyID string
 
 	if isParty1 {
+		partyID = rc.party1ID
 		partyCollection1 = rc.party1TxCollection
-		partyCollection2 = rc.party2TxCollection
-
-		notFoundResultType = recon.ResultParty1Only
 
 		var temp T1
 		partyTransaction1 = temp
 	} else {
+		partyID = rc.party2ID
 		partyCollection1 = rc.party2TxCollection
-		partyCollection2 = rc.party1TxCollection
-
-		notFoundResultType = recon.ResultParty2Only
 
 		var temp T2
 		partyTransaction1 = temp
@@ -204,11 +918,71 @@ func (rc *Reconciler[T1, T2]) compare(
 
 	err := partyCollection1.Read(ctx, &partyTransaction1)
 	if err != nil {
-		return err
+		if errors.Is(err, io.EOF) {
+			return nil, err
+		}
+
+		return nil, &RecordError{PartyID: partyID, Err: err}
+	}
+
+	return partyTransaction1, nil
+}
+
+// reconcile reconciles a transaction of a party against the other party
+func (rc *Reconciler[T1, T2]) reconcile(
+	ctx context.Context,
+	reconResult *ReconResult,
+	isParty1 bool,
+	partyTransaction1 domain.Transaction,
+) error {
+	var notFoundResultType string
+
+	var partyCollection2 Collection
+
+	var partyID string
+
+	if isParty1 {
+		partyID = rc.party1ID
+		partyCollection2 = rc.party2TxCollection
+
+		notFoundResultType = recon.ResultParty1Only
+	} else {
+		partyID = rc.party2ID
+		partyCollection2 = rc.party1TxCollection
+
+		notFoundResultType = recon.ResultParty2Only
 	}
 
+	balanceChecker := rc.getBalanceChecker(isParty1)
+
+	var tracer *traceRecorder
+
+	if len(rc.traceTargets) > 0 {
+		if rc.isTraced(partyTransaction1) {
+			tracer = newTraceRecorder(partyID)
+		}
+
+		// the tracer may be created once the counterpart is found
+		defer func() {
+			if tracer != nil {
+				rc.attachTrace(reconResult, tracer)
+			}
+		}()
+	}
+
+	tracer.identify(partyTransaction1)
+	tracer.record(TraceStageRead, nil, "read transaction %s", partyTransaction1.GetID())
+
 	if rc.filter != nil {
-		pass, errF := rc.filter.Filter(ctx, partyTransaction1)
+		filterCtx := ctx
+		if tracer != nil {
+			filterCtx = context.WithValue(ctx, traceRecorderKey{}, tracer)
+		}
+
+		pass, errF := rc.filter.Filter(filterCtx, partyTransaction1)
+
+		tracer.recordFilter(rc.filter, pass, errF)
+
 		if errF != nil {
 			return errF
 		}
@@ -223,6 +997,21 @@ func (rc *Reconciler[T1, T2]) compare(
 
 	partyTransaction2, found := partyCollection2.Find(ctx, matchingKey)
 
+	if found && tracer == nil && rc.isTraced(partyTransaction2) {
+		tracer = newTraceRecorder(partyID)
+
+		tracer.identify(partyTransaction1)
+		tracer.record(TraceStageRead, nil, "read transaction %s, traced by its counterpart", partyTransaction1.GetID())
+	}
+
+	if found {
+		tracer.identify(partyTransaction2)
+		tracer.record(TraceStageFind, map[string]any{"found": true},
+			"found counterpart %s by matching key %s", partyTransaction2.GetID(), matchingKey)
+	} else {
+		tracer.record(TraceStageFind, map[string]any{"found": false}, "no counterpart by matching key %s", matchingKey)
+	}
+
 	var party1Transaction, party2Transaction domain.Transaction
 
 	if isParty1 {
@@ -238,12 +1027,27 @@ func (rc *Reconciler[T1, T2]) compare(
 		return err
 	}
 
+	for _, txReconItem := range txReconItems {
+		tracer.record(TraceStageCompare, describeItem(txReconItem), "compared %s by %T", txReconItem.Key, rc.comparator)
+	}
+
 	var resultType string
 
 	if found {
-		resultType = rc.deriveResultType(txReconItems)
+		resultType, err = rc.deriveResultType(ctx, txReconItems)
+		if err != nil {
+			return err
+		}
+
+		if rc.classifier != nil {
+			tracer.record(TraceStageClassify, nil, "classified as %s by %T", resultType, rc.classifier)
+		} else {
+			tracer.record(TraceStageClassify, nil, "classified as %s by default rules", resultType)
+		}
 	} else {
 		resultType = notFoundResultType
+
+		tracer.record(TraceStageClassify, nil, "classified as %s since no counterpart", resultType)
 	}
 
 	txReconResult, err := rc.buildResult(
@@ -257,20 +1061,145 @@ func (rc *Reconciler[T1, T2]) compare(
 		return err
 	}
 
-	if found {
-		reconResult.BothParties[matchingKey] = txReconResult
-	} else {
-		if isParty1 {
-			reconResult.Party1Only[partyTransaction1.GetID()] = txReconResult
-		} else {
-			reconResult.Party2Only[partyTransaction1.GetID()] = txReconResult
+	if rc.pendingStore != nil {
+		txReconResult.ResultType, err = rc.derivePendingResultType(
+			ctx, matchingKey, party1Transaction, party2Transaction, resultType)
+		if err != nil {
+			return err
+		}
+
+		tracer.record(TraceStagePending, nil, "result type is %s after pending check", txReconResult.ResultType)
+	}
+
+	if rc.scorer != nil {
+		severity, errS := rc.scorer.Score(ctx, txReconResult)
+
+		var unscorableError *UnscorableError
+
+		switch {
+		case errors.As(errS, &unscorableError):
+			txReconResult.UnscorableReason = unscorableError.Err.Error()
+
+			tracer.record(TraceStageScore, nil, "unscorable by %T: %s", rc.scorer, txReconResult.UnscorableReason)
+		case errS != nil:
+			return fmt.Errorf("could not score %s: %w", matchingKey, errS)
+		default:
+			txReconResult.Severity = &severity
+
+			tracer.record(TraceStageScore, nil, "scored %s by %T", severity.String(), rc.scorer)
+		}
+	}
+
+	var results map[string]*domain.TxReconResult
+
+	var resultKey string
+
+	var changed map[string]struct{}
+
+	switch {
+	case found:
+		results, resultKey = reconResult.BothParties, matchingKey
+	case isParty1:
+		results, resultKey = reconResult.Party1Only, partyTransaction1.GetID()
+	default:
+		results, resultKey = reconResult.Party2Only, partyTransaction1.GetID()
+	}
+
+	if rc.delta != nil {
+		switch {
+		case found:
+			changed = rc.delta.bothParties
+		case isParty1:
+			changed = rc.delta.party1Only
+		default:
+			changed = rc.delta.party2Only
+		}
+	}
+
+	// the movement of a skipped record is not counted, so it is added once the record is reconciled
+	if balanceChecker != nil {
+		err = balanceChecker.Add(ctx, partyTransaction1)
+		if err != nil {
+			return err
+		}
+	}
+
+	// a transaction found at both parties is reconciled twice, emit it only once
+	_, emitted := results[resultKey]
+
+	results[resultKey] = txReconResult
+
+	if changed != nil {
+		changed[resultKey] = struct{}{}
+	}
+
+	if emitted {
+		return nil
+	}
+
+	if rc.sink != nil {
+		err = rc.sink.Emit(ctx, txReconResult)
+		if err != nil {
+			return fmt.Errorf("could not emit result of %s: %w", matchingKey, err)
 		}
 	}
 
+	for _, listener := range rc.listeners {
+		listener.OnResult(ctx, txReconResult)
+	}
+
 	return nil
 }
 
-func (rc *Reconciler[T1, T2]) deriveResultType(txReconItems []*domain.TxReconItem) string {
+// isTraced returns whether the matching key or an ID of the transaction is a trace target.
+func (rc *Reconciler[T1, T2]) isTraced(transaction domain.Transaction) bool {
+	if _, traced := rc.traceTargets[transaction.GetMatchingKey()]; traced {
+		return true
+	}
+
+	if _, traced := rc.traceTargets[transaction.GetID()]; traced {
+		return true
+	}
+
+	if externalID := transaction.GetExternalID(); externalID != nil {
+		if _, traced := rc.traceTargets[*externalID]; traced {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (rc *Reconciler[T1, T2]) attachTrace(reconResult *ReconResult, tracer *traceRecorder) {
+	for target := range tracer.targets {
+		if _, traced := rc.traceTargets[target]; !traced {
+			continue
+		}
+
+		trace, found := reconResult.Traces[target]
+		if !found {
+			trace = &Trace{Target: target}
+			reconResult.Traces[target] = trace
+		}
+
+		trace.Steps = append(trace.Steps, tracer.steps...)
+	}
+}
+
+func (rc *Reconciler[T1, T2]) deriveResultType(ctx context.Context, txReconItems []*domain.TxReconItem) (string, error) {
+	if rc.classifier != nil {
+		resultType, err := rc.classifier.Classify(ctx, txReconItems)
+		if err != nil {
+			return "", fmt.Errorf("could not classify result: %w", err)
+		}
+
+		if !recon.IsBothPartiesResultType(resultType) {
+			return "", &recon.UnknownResultTypeError{ResultType: resultType}
+		}
+
+		return resultType, nil
+	}
+
 	var mismatchedType string
 
 	for _, reconItem := range txReconItems {
@@ -278,18 +1207,94 @@ func (rc *Reconciler[T1, T2]) deriveResultType(txReconItems []*domain.TxReconIte
 			continue
 		}
 
+		itemResultType, found := defaultItemResultTypes[reconItem.Type]
+		if !found {
+			return recon.ResultMismatched, nil
+		}
+
 		if mismatchedType == "" {
-			mismatchedType = reconItem.Type
-		} else if mismatchedType != reconItem.Type {
-			return recon.ResultMismatched
+			mismatchedType = itemResultType
+		} else if mismatchedType != itemResultType {
+			return recon.ResultMismatched, nil
 		}
 	}
 
 	if mismatchedType == "" {
-		return recon.ResultMatched
+		return recon.ResultMatched, nil
+	}
+
+	return mismatchedType, nil
+}
+
+// derivePendingResultType returns pending if a transaction is in a non-terminal state and the break is younger than
+// the SLA, otherwise it returns the result type as is.
+func (rc *Reconciler[T1, T2]) derivePendingResultType(
+	ctx context.Context,
+	matchingKey string,
+	partyTransaction1, partyTransaction2 domain.Transaction,
+	resultType string,
+) (string, error) {
+	if resultType == recon.ResultMatched || (!isPending(partyTransaction1) && !isPending(partyTransaction2)) {
+		_, found, err := rc.pendingStore.Get(ctx, matchingKey)
+		if err != nil {
+			return "", fmt.Errorf("could not get %s from pending store: %w", matchingKey, err)
+		}
+
+		if !found {
+			return resultType, nil
+		}
+
+		// resolved, forget it
+		err = rc.pendingStore.Delete(ctx, matchingKey)
+		if err != nil {
+			return "", fmt.Errorf("could not delete %s from pending store: %w", matchingKey, err)
+		}
+
+		if rc.delta != nil {
+			rc.delta.pending[matchingKey] = nil
+		}
+
+		return resultType, nil
 	}
 
-	return mismatchedType
+	now := rc.now()
+
+	since, found, err := rc.pendingStore.Get(ctx, matchingKey)
+	if err != nil {
+		return "", fmt.Errorf("could not get %s from pending store: %w", matchingKey, err)
+	}
+
+	if !found {
+		since = now
+
+		err = rc.pendingStore.Put(ctx, matchingKey, since)
+		if err != nil {
+			return "", fmt.Errorf("could not put %s into pending store: %w", matchingKey, err)
+		}
+
+		if rc.delta != nil {
+			rc.delta.pending[matchingKey] = &since
+		}
+	}
+
+	if now.Sub(since) <= rc.pendingSLA {
+		return recon.ResultPending, nil
+	}
+
+	rc.logger.Info("pending transaction exceeds SLA, escalate",
+		slog.String("matching_key", matchingKey), slog.Time("since", since), slog.String("result_type", resultType))
+
+	return resultType, nil
+}
+
+func isPending(transaction domain.Transaction) bool {
+	if transaction == nil {
+		return false
+	}
+
+	pendingTransaction, cok := transaction.(domain.PendingTransaction)
+
+	return cok && pendingTransaction.IsPending()
 }
 
 func (rc *Reconciler[T1, T2]) buildResult(
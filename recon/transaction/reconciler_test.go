@@ -0,0 +1,243 @@
+package transaction
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/shopspring/decimal"
+
+	"github.com/ivxivx/go-recon/recon"
+	"github.com/ivxivx/go-recon/recon/domain"
+)
+
+type testTransaction struct {
+	id      string
+	key     string
+	amount  string
+	pending bool
+}
+
+func (t *testTransaction) GetMatchingKey() string  { return t.key }
+func (t *testTransaction) GetID() string           { return t.id }
+func (t *testTransaction) GetExternalID() *string  { return nil }
+func (t *testTransaction) GetType() string         { return "payout" }
+func (t *testTransaction) GetTimestamp() time.Time { return time.Time{} }
+func (t *testTransaction) IsPending() bool         { return t.pending }
+
+type testPendingStore map[string]time.Time
+
+func (s testPendingStore) Get(_ context.Context, matchingKey string) (time.Time, bool, error) {
+	since, found := s[matchingKey]
+
+	return since, found, nil
+}
+
+func (s testPendingStore) Put(_ context.Context, matchingKey string, since time.Time) error {
+	s[matchingKey] = since
+
+	return nil
+}
+
+func (s testPendingStore) Delete(_ context.Context, matchingKey string) error {
+	delete(s, matchingKey)
+
+	return nil
+}
+
+func Test_Reconciler_DerivePendingResultType(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name         string
+		transaction1 *testTransaction
+		transaction2 *testTransaction
+		resultType   string
+		since        *time.Time
+		expected     string
+		stored       bool
+	}{
+		{
+			name:         "matched",
+			transaction1: &testTransaction{key: "k", pending: true},
+			transaction2: &testTransaction{key: "k", pending: true},
+			resultType:   recon.ResultMatched,
+			since:        &now,
+			expected:     recon.ResultMatched,
+		},
+		{
+			name:         "resolved",
+			transaction1: &testTransaction{key: "k"},
+			transaction2: &testTransaction{key: "k"},
+			resultType:   recon.ResultStatusMismatched,
+			since:        &now,
+			expected:     recon.ResultStatusMismatched,
+		},
+		{
+			name:         "first seen pending",
+			transaction1: &testTransaction{key: "k", pending: true},
+			resultType:   recon.ResultParty1Only,
+			expected:     recon.ResultPending,
+			stored:       true,
+		},
+		{
+			name:         "pending within SLA",
+			transaction1: &testTransaction{key: "k"},
+			transaction2: &testTransaction{key: "k", pending: true},
+			resultType:   recon.ResultStatusMismatched,
+			since:        ptr(now.Add(-time.Hour)),
+			expected:     recon.ResultPending,
+			stored:       true,
+		},
+		{
+			name:         "pending past SLA",
+			transaction1: &testTransaction{key: "k", pending: true},
+			resultType:   recon.ResultParty1Only,
+			since:        ptr(now.Add(-25 * time.Hour)),
+			expected:     recon.ResultParty1Only,
+			stored:       true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			store := testPendingStore{}
+			if tc.since != nil {
+				store["k"] = *tc.since
+			}
+
+			rc := NewReconciler[*testTransaction, *testTransaction](slog.Default(), "party1", "party2", nil, nil, nil).
+				WithPendingStore(store, 24*time.Hour)
+			rc.now = func() time.Time { return now }
+
+			var transaction2 domain.Transaction
+			if tc.transaction2 != nil {
+				transaction2 = tc.transaction2
+			}
+
+			resultType, err := rc.derivePendingResultType(context.Background(), "k", tc.transaction1, transaction2, tc.resultType)
+			if err != nil {
+				t.Fatalf("failed to derive result type: %v", err)
+			}
+
+			if resultType != tc.expected {
+				t.Fatalf("expected %s, got %s", tc.expected, resultType)
+			}
+
+			if _, stored := store["k"]; stored != tc.stored {
+				t.Fatalf("expected stored=%t, got %t", tc.stored, stored)
+			}
+		})
+	}
+}
+
+type testClassifier string
+
+func (c testClassifier) Classify(_ context.Context, _ []*domain.TxReconItem) (string, error) {
+	return string(c), nil
+}
+
+func Test_Reconciler_DeriveResultType(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name       string
+		classifier ResultClassifier
+		items      []*domain.TxReconItem
+		expected   string
+		invalid    bool
+	}{
+		{
+			name:     "default single type",
+			items:    []*domain.TxReconItem{{Type: string(domain.ItemTypeAmount)}, {Type: string(domain.ItemTypeStatus), Matched: true}},
+			expected: recon.ResultAmountMismatched,
+		},
+		{
+			name:     "default multiple types",
+			items:    []*domain.TxReconItem{{Type: string(domain.ItemTypeAmount)}, {Type: string(domain.ItemTypeStatus)}},
+			expected: recon.ResultMismatched,
+		},
+		{
+			name:       "classifier",
+			classifier: testClassifier(recon.ResultStatusMismatched),
+			expected:   recon.ResultStatusMismatched,
+		},
+		{
+			name:       "classifier returns a result type of a single party",
+			classifier: testClassifier(recon.ResultParty1Only),
+			invalid:    true,
+		},
+		{
+			name:       "classifier returns pending",
+			classifier: testClassifier(recon.ResultPending),
+			invalid:    true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			rc := NewReconciler[*testTransaction, *testTransaction](slog.Default(), "party1", "party2", nil, nil, nil)
+			if tc.classifier != nil {
+				rc.WithResultClassifier(tc.classifier)
+			}
+
+			resultType, err := rc.deriveResultType(context.Background(), tc.items)
+
+			if tc.invalid {
+				var unknownResultTypeError *recon.UnknownResultTypeError
+				if !errors.As(err, &unknownResultTypeError) {
+					t.Fatalf("expected unknown result type, got %s, %v", resultType, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("failed to derive result type: %v", err)
+			}
+
+			if resultType != tc.expected {
+				t.Fatalf("expected %s, got %s", tc.expected, resultType)
+			}
+		})
+	}
+}
+
+func Test_ReconResult_GetBreaksBySeverity(t *testing.T) {
+	t.Parallel()
+
+	reconResult := &ReconResult{
+		BothParties: map[string]*domain.TxReconResult{
+			"k1": {MatchingKey: "k1", ResultType: recon.ResultAmountMismatched, Severity: ptr(decimal.NewFromInt(10))},
+			"k2": {MatchingKey: "k2", ResultType: recon.ResultMatched},
+			"k3": {MatchingKey: "k3", ResultType: recon.ResultAmountMismatched, UnscorableReason: "no exchange rate"},
+		},
+		Party1Only: map[string]*domain.TxReconResult{
+			"t4": {MatchingKey: "k4", ResultType: recon.ResultParty1Only, Severity: ptr(decimal.NewFromInt(20))},
+			"t5": {MatchingKey: "k5", ResultType: recon.ResultPending},
+		},
+	}
+
+	var matchingKeys []string
+
+	for _, txReconResult := range reconResult.GetBreaksBySeverity() {
+		matchingKeys = append(matchingKeys, txReconResult.MatchingKey)
+	}
+
+	if diff := cmp.Diff([]string{"k3", "k4", "k1"}, matchingKeys); diff != "" {
+		t.Fatalf("unexpected order (-want +got):\n%s", diff)
+	}
+}
+
+func ptr[T any](s T) *T {
+	return &s
+}
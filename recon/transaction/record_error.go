@@ -0,0 +1,40 @@
+package transaction
+
+import (
+	"fmt"
+)
+
+type ErrorPolicy string
+
+const (
+	// ErrorPolicyFailFast stops processing at the first error
+	ErrorPolicyFailFast ErrorPolicy = "fail_fast"
+	// ErrorPolicyContinue skips the offending record and collects the error into ReconResult.Errors
+	ErrorPolicyContinue ErrorPolicy = "continue"
+)
+
+const (
+	// MaxConsecutiveReadErrors stops reading if it keeps failing, the reader may not be able to move forward
+	MaxConsecutiveReadErrors = 10
+)
+
+// RecordError is an error of processing a single record.
+type RecordError struct {
+	PartyID       string `json:"party_id"`
+	TransactionID string `json:"transaction_id,omitempty"` // empty if the record could not be read
+	MatchingKey   string `json:"matching_key,omitempty"`
+	Record        any    `json:"record,omitempty"`
+	Err           error  `json:"-"`
+}
+
+func (e *RecordError) Error() string {
+	if e.TransactionID == "" {
+		return fmt.Sprintf("could not read record of %s: %v", e.PartyID, e.Err)
+	}
+
+	return fmt.Sprintf("could not process transaction %s of %s: %v", e.TransactionID, e.PartyID, e.Err)
+}
+
+func (e *RecordError) Unwrap() error {
+	return e.Err
+}
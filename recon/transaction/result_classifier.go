@@ -0,0 +1,28 @@
+package transaction
+
+import (
+	"context"
+	"maps"
+
+	"github.com/ivxivx/go-recon/recon"
+	"github.com/ivxivx/go-recon/recon/domain"
+)
+
+// ResultClassifier derives the result type from the items of a transaction found at both parties.
+// The result type must be one of those accepted by recon.IsBothPartiesResultType.
+type ResultClassifier interface {
+	Classify(ctx context.Context, items []*domain.TxReconItem) (string, error)
+}
+
+// item type -> result type of a break of that single type
+var defaultItemResultTypes = map[string]string{
+	string(domain.ItemTypeStatus):   recon.ResultStatusMismatched,
+	string(domain.ItemTypeAmount):   recon.ResultAmountMismatched,
+	string(domain.ItemTypeCurrency): recon.ResultCurrencyMismatched,
+}
+
+// DefaultItemResultTypes returns a new map of an item type to the result type of a break of that single type,
+// any other item type is a recon.ResultMismatched break.
+func DefaultItemResultTypes() map[string]string {
+	return maps.Clone(defaultItemResultTypes)
+}
@@ -0,0 +1,28 @@
+package transaction
+
+import (
+	"context"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/ivxivx/go-recon/recon/domain"
+)
+
+// Scorer gives a recon result a severity, the higher the more urgent.
+// It fails with UnscorableError if the result has no severity, e.g. an exchange rate is missing,
+// the result is then marked unscorable and the run goes on.
+type Scorer interface {
+	Score(ctx context.Context, result *domain.TxReconResult) (decimal.Decimal, error)
+}
+
+type UnscorableError struct {
+	Err error
+}
+
+func (e *UnscorableError) Error() string {
+	return "unscorable: " + e.Err.Error()
+}
+
+func (e *UnscorableError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,14 @@
+package scorer
+
+import "errors"
+
+var ErrUnknownCurrency = errors.New("currency is unknown")
+
+type MissingRateError struct {
+	FromCurrency string
+	ToCurrency   string
+}
+
+func (e *MissingRateError) Error() string {
+	return "no exchange rate from " + e.FromCurrency + " to " + e.ToCurrency
+}
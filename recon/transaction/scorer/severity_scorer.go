@@ -0,0 +1,201 @@
+package scorer
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/ivxivx/go-recon/recon"
+	"github.com/ivxivx/go-recon/recon/domain"
+	txn "github.com/ivxivx/go-recon/recon/transaction"
+)
+
+const (
+	scale = 2
+)
+
+var hoursPerDay = decimal.NewFromInt(24)
+
+// SeverityScorer scores a break by its amount at risk converted to the reporting currency,
+// weighted by result type and age.
+//
+// severity = amount at risk * rate * result type weight * (1 + aging rate * age in days)
+type SeverityScorer struct {
+	reportingCurrency string
+	// currency -> rate to reporting currency
+	rates map[string]decimal.Decimal
+	// result type -> weight, default weight is 1
+	resultTypeWeights map[string]decimal.Decimal
+	agingRate         decimal.Decimal
+
+	now func() time.Time
+}
+
+func NewSeverityScorer(reportingCurrency string, rates map[string]decimal.Decimal) *SeverityScorer {
+	return &SeverityScorer{
+		reportingCurrency: reportingCurrency,
+		rates:             rates,
+		resultTypeWeights: map[string]decimal.Decimal{},
+		agingRate:         decimal.Zero,
+		now:               time.Now,
+	}
+}
+
+func (s *SeverityScorer) WithResultTypeWeights(weights map[string]decimal.Decimal) *SeverityScorer {
+	if weights != nil {
+		s.resultTypeWeights = weights
+	}
+
+	return s
+}
+
+// WithAgingRate sets the weight added per day of age, e.g. 0.1 adds 10% per day.
+func (s *SeverityScorer) WithAgingRate(agingRate decimal.Decimal) *SeverityScorer {
+	s.agingRate = agingRate
+
+	return s
+}
+
+func (s *SeverityScorer) WithClock(now func() time.Time) *SeverityScorer {
+	s.now = now
+
+	return s
+}
+
+var _ txn.Scorer = (*SeverityScorer)(nil)
+
+func (s *SeverityScorer) Score(_ context.Context, result *domain.TxReconResult) (decimal.Decimal, error) {
+	if result.ResultType == recon.ResultMatched {
+		return decimal.Zero, nil
+	}
+
+	amountAtRisk, err := s.amountAtRisk(result)
+	if err != nil {
+		return decimal.Zero, &txn.UnscorableError{Err: err}
+	}
+
+	if amountAtRisk.IsZero() {
+		return decimal.Zero, nil
+	}
+
+	rate, err := s.rate(result)
+	if err != nil {
+		return decimal.Zero, &txn.UnscorableError{Err: err}
+	}
+
+	weight, found := s.resultTypeWeights[result.ResultType]
+	if !found {
+		weight = decimal.NewFromInt(1)
+	}
+
+	var ageInDays decimal.Decimal
+
+	if age := s.now().Sub(result.TransactionTimestamp); age > 0 {
+		ageInDays = decimal.NewFromFloat(age.Hours()).Div(hoursPerDay)
+	}
+
+	ageWeight := decimal.NewFromInt(1).Add(s.agingRate.Mul(ageInDays))
+
+	return amountAtRisk.Mul(rate).Mul(weight).Mul(ageWeight).Round(scale), nil
+}
+
+// amountAtRisk returns the largest absolute difference, or the amount of the only party if there is no difference.
+func (s *SeverityScorer) amountAtRisk(result *domain.TxReconResult) (decimal.Decimal, error) {
+	amountAtRisk := decimal.Zero
+
+	for _, item := range result.Items {
+		if item.Matched {
+			continue
+		}
+
+		var amount decimal.Decimal
+
+		switch {
+		case item.Difference != nil:
+			amount = item.Difference.Abs()
+		case item.Type == string(domain.ItemTypeAmount):
+			amount1, err := parseAmount(item.Key, item.PartyValue1)
+			if err != nil {
+				return decimal.Zero, err
+			}
+
+			amount2, err := parseAmount(item.Key, item.PartyValue2)
+			if err != nil {
+				return decimal.Zero, err
+			}
+
+			if item.PartyValue1 != nil && item.PartyValue2 != nil {
+				amount = amount2.Sub(amount1).Abs()
+			} else {
+				amount = decimal.Max(amount1.Abs(), amount2.Abs())
+			}
+		default:
+			continue
+		}
+
+		if amount.GreaterThan(amountAtRisk) {
+			amountAtRisk = amount
+		}
+	}
+
+	// e.g. status break, the full amount is at risk
+	if amountAtRisk.IsZero() {
+		if item, found := result.FindItem(domain.ItemTypeAmount); found {
+			amount1, err := parseAmount(item.Key, item.PartyValue1)
+			if err != nil {
+				return decimal.Zero, err
+			}
+
+			amount2, err := parseAmount(item.Key, item.PartyValue2)
+			if err != nil {
+				return decimal.Zero, err
+			}
+
+			amountAtRisk = decimal.Max(amount1.Abs(), amount2.Abs())
+		}
+	}
+
+	return amountAtRisk, nil
+}
+
+func (s *SeverityScorer) rate(result *domain.TxReconResult) (decimal.Decimal, error) {
+	var currency string
+
+	if item, found := result.FindItem(domain.ItemTypeCurrency); found {
+		if item.PartyValue1 != nil {
+			currency = *item.PartyValue1
+		} else if item.PartyValue2 != nil {
+			currency = *item.PartyValue2
+		}
+	}
+
+	if currency == "" {
+		return decimal.Zero, ErrUnknownCurrency
+	}
+
+	if currency == s.reportingCurrency {
+		return decimal.NewFromInt(1), nil
+	}
+
+	rate, found := s.rates[currency]
+	if !found {
+		return decimal.Zero, &MissingRateError{FromCurrency: currency, ToCurrency: s.reportingCurrency}
+	}
+
+	return rate, nil
+}
+
+func parseAmount(key string, value *string) (decimal.Decimal, error) {
+	if value == nil {
+		return decimal.Zero, nil
+	}
+
+	amount, err := decimal.NewFromString(*value)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("could not parse %s %q: %w", key, *value, err)
+	}
+
+	return amount, nil
+}
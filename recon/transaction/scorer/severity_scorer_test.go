@@ -0,0 +1,158 @@
+package scorer
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/ivxivx/go-recon/recon"
+	"github.com/ivxivx/go-recon/recon/domain"
+	txn "github.com/ivxivx/go-recon/recon/transaction"
+)
+
+func Test_SeverityScorer(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 8, 3, 0, 0, 0, 0, time.UTC)
+
+	currencyItem := func(currency *string) *domain.TxReconItem {
+		return &domain.TxReconItem{Type: string(domain.ItemTypeCurrency), Key: "currency", PartyValue1: currency, Matched: true}
+	}
+
+	testCases := []struct {
+		name       string
+		result     *domain.TxReconResult
+		severity   string
+		unscorable bool
+	}{
+		{
+			name: "matched",
+			result: &domain.TxReconResult{
+				ResultType: recon.ResultMatched,
+			},
+			severity: "0",
+		},
+		{
+			name: "difference converted and aged",
+			result: &domain.TxReconResult{
+				ResultType:           recon.ResultAmountMismatched,
+				TransactionTimestamp: now.Add(-48 * time.Hour),
+				Items: []*domain.TxReconItem{
+					currencyItem(ptr("EUR")),
+					{Type: string(domain.ItemTypeAmount), Key: "amount", PartyValue1: ptr("100"), PartyValue2: ptr("90"), Difference: ptr(decimal.RequireFromString("-10"))},
+				},
+			},
+			// 10 * 1.1 * 2 * (1 + 0.1 * 2)
+			severity: "26.4",
+		},
+		{
+			name: "amount without difference uses the difference",
+			result: &domain.TxReconResult{
+				ResultType:           recon.ResultAmountMismatched,
+				TransactionTimestamp: now,
+				Items: []*domain.TxReconItem{
+					currencyItem(ptr("USD")),
+					{Type: string(domain.ItemTypeAmount), Key: "amount", PartyValue1: ptr("100"), PartyValue2: ptr("90")},
+				},
+			},
+			severity: "20",
+		},
+		{
+			name: "party only at full amount",
+			result: &domain.TxReconResult{
+				ResultType:           recon.ResultParty1Only,
+				TransactionTimestamp: now,
+				Items: []*domain.TxReconItem{
+					currencyItem(ptr("USD")),
+					{Type: string(domain.ItemTypeAmount), Key: "amount", PartyValue1: ptr("-100")},
+				},
+			},
+			severity: "100",
+		},
+		{
+			name: "status break at full amount",
+			result: &domain.TxReconResult{
+				ResultType:           recon.ResultStatusMismatched,
+				TransactionTimestamp: now,
+				Items: []*domain.TxReconItem{
+					currencyItem(ptr("USD")),
+					{Type: string(domain.ItemTypeStatus), Key: "status", PartyValue1: ptr("completed"), PartyValue2: ptr("Canceled")},
+					{Type: string(domain.ItemTypeAmount), Key: "amount", PartyValue1: ptr("100"), PartyValue2: ptr("100"), Matched: true},
+				},
+			},
+			severity: "100",
+		},
+		{
+			name: "missing rate",
+			result: &domain.TxReconResult{
+				ResultType: recon.ResultAmountMismatched,
+				Items: []*domain.TxReconItem{
+					currencyItem(ptr("JPY")),
+					{Type: string(domain.ItemTypeAmount), Key: "amount", Difference: ptr(decimal.RequireFromString("10"))},
+				},
+			},
+			unscorable: true,
+		},
+		{
+			name: "unknown currency",
+			result: &domain.TxReconResult{
+				ResultType: recon.ResultParty2Only,
+				Items: []*domain.TxReconItem{
+					currencyItem(nil),
+					{Type: string(domain.ItemTypeAmount), Key: "amount", PartyValue2: ptr("10")},
+				},
+			},
+			unscorable: true,
+		},
+		{
+			name: "unparsable amount",
+			result: &domain.TxReconResult{
+				ResultType: recon.ResultParty1Only,
+				Items: []*domain.TxReconItem{
+					currencyItem(ptr("USD")),
+					{Type: string(domain.ItemTypeAmount), Key: "amount", PartyValue1: ptr("1,000")},
+				},
+			},
+			unscorable: true,
+		},
+	}
+
+	scorer := NewSeverityScorer("USD", map[string]decimal.Decimal{"EUR": decimal.RequireFromString("1.1")}).
+		WithResultTypeWeights(map[string]decimal.Decimal{
+			recon.ResultAmountMismatched: decimal.NewFromInt(2),
+		}).
+		WithAgingRate(decimal.RequireFromString("0.1")).
+		WithClock(func() time.Time { return now })
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			severity, err := scorer.Score(context.Background(), tc.result)
+
+			if tc.unscorable {
+				var unscorableError *txn.UnscorableError
+				if !errors.As(err, &unscorableError) {
+					t.Fatalf("expected unscorable, got %v", err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("failed to score: %v", err)
+			}
+
+			if !severity.Equal(decimal.RequireFromString(tc.severity)) {
+				t.Fatalf("expected severity %s, got %s", tc.severity, severity)
+			}
+		})
+	}
+}
+
+func ptr[T any](s T) *T {
+	return &s
+}
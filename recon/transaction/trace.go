@@ -0,0 +1,119 @@
+package transaction
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ivxivx/go-recon/recon/domain"
+)
+
+type TraceStage string
+
+const (
+	TraceStageRead     TraceStage = "read"
+	TraceStageFilter   TraceStage = "filter"
+	TraceStageFind     TraceStage = "find"
+	TraceStageCompare  TraceStage = "compare"
+	TraceStageClassify TraceStage = "classify"
+	TraceStagePending  TraceStage = "pending"
+	TraceStageScore    TraceStage = "score"
+)
+
+type TraceStep struct {
+	Stage TraceStage `json:"stage"`
+	// party whose collection is iterated
+	PartyID string         `json:"party_id"`
+	Message string         `json:"message"`
+	Details map[string]any `json:"details,omitempty"`
+}
+
+// Trace records every decision made for a traced matching key or transaction ID.
+type Trace struct {
+	Target string       `json:"target"`
+	Steps  []*TraceStep `json:"steps"`
+}
+
+// traceRecorder collects steps of one transaction, a nil recorder records nothing.
+type traceRecorder struct {
+	partyID string
+	steps   []*TraceStep
+	targets map[string]struct{}
+}
+
+type traceRecorderKey struct{}
+
+func newTraceRecorder(partyID string) *traceRecorder {
+	return &traceRecorder{partyID: partyID, targets: make(map[string]struct{})}
+}
+
+// TraceFilter records the verdict of a filter nested in a composite filter if the transaction is traced,
+// it is called by the composite filter with the context passed to it.
+func TraceFilter(ctx context.Context, filter Filter, pass bool, err error) {
+	tracer, _ := ctx.Value(traceRecorderKey{}).(*traceRecorder)
+
+	tracer.recordFilter(filter, pass, err)
+}
+
+func (tr *traceRecorder) recordFilter(filter Filter, pass bool, err error) {
+	if err != nil {
+		tr.record(TraceStageFilter, map[string]any{"error": err.Error()}, "filter %T failed: %v", filter, err)
+
+		return
+	}
+
+	tr.record(TraceStageFilter, map[string]any{"pass": pass}, "filter %T: pass=%t", filter, pass)
+}
+
+func (tr *traceRecorder) record(stage TraceStage, details map[string]any, format string, args ...any) {
+	if tr == nil {
+		return
+	}
+
+	tr.steps = append(tr.steps, &TraceStep{
+		Stage:   stage,
+		PartyID: tr.partyID,
+		Message: fmt.Sprintf(format, args...),
+		Details: details,
+	})
+}
+
+// identify adds the matching key and IDs of a transaction as candidates of trace targets.
+func (tr *traceRecorder) identify(transaction domain.Transaction) {
+	if tr == nil || transaction == nil {
+		return
+	}
+
+	tr.targets[transaction.GetMatchingKey()] = struct{}{}
+	tr.targets[transaction.GetID()] = struct{}{}
+
+	if externalID := transaction.GetExternalID(); externalID != nil {
+		tr.targets[*externalID] = struct{}{}
+	}
+}
+
+func describeItem(item *domain.TxReconItem) map[string]any {
+	details := map[string]any{
+		"type":    item.Type,
+		"key":     item.Key,
+		"matched": item.Matched,
+	}
+
+	if item.PartyValue1 != nil {
+		details["party_value1"] = *item.PartyValue1
+	}
+
+	if item.PartyValue2 != nil {
+		details["party_value2"] = *item.PartyValue2
+	}
+
+	if item.Difference != nil {
+		details["difference"] = item.Difference.String()
+	}
+
+	if item.ReasonCode != "" {
+		details["reason_code"] = string(item.ReasonCode)
+		details["reason"] = item.Reason
+	}
+
+	return details
+}